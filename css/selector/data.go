@@ -71,6 +71,12 @@ const (
 	Contains
 	// Test that an attribute starts with a value or a value with a dash.
 	DashPrefix
+	// Test that an attribute starts with a value.
+	Prefix
+	// Test that an attribute ends with a value.
+	Suffix
+	// Test that an attribute contains a value as a substring.
+	Substring
 )
 
 func (t attrMatchType) String() string {
@@ -83,6 +89,12 @@ func (t attrMatchType) String() string {
 		return "~="
 	case DashPrefix:
 		return "|="
+	case Prefix:
+		return "^="
+	case Suffix:
+		return "$="
+	case Substring:
+		return "*="
 	}
 	panic("Unreachable")
 }
@@ -99,6 +111,12 @@ type SimpleSelector struct {
 	Value string
 	// The attribute name if Type is Attr
 	AttrName string
+	// The argument of a functional Pseudoclass such as :lang(en)
+	Arg string
+	// The parsed argument of a Pseudoclass taking a selector such as :not
+	sub *Chain
+	// The parsed relative selector argument of :has
+	rel []Link
 }
 
 const (
@@ -123,18 +141,121 @@ func attrExactly(val string, a *html.Attribute) bool {
 	return val == a.Val
 }
 
+// attrSubstring reports whether the attribute matches val with the
+// substring matcher m. An empty val never matches.
+func attrSubstring(m attrMatchType, val string, a *html.Attribute) bool {
+	if val == "" {
+		return false
+	}
+	switch m {
+	case Prefix:
+		return strings.HasPrefix(a.Val, val)
+	case Suffix:
+		return strings.HasSuffix(a.Val, val)
+	}
+	return strings.Contains(a.Val, val)
+}
+
+// argChain returns the selector argument of the PseudoClass parsing it
+// if the SimpleSelector wasn't constructed by the parser.
+func (ss SimpleSelector) argChain() *Chain {
+	if ss.sub != nil {
+		return ss.sub
+	}
+	chn, err := Selector(ss.Arg)
+	if err != nil {
+		panic(fmt.Errorf("Invalid argument to PseudoClass %s: %s", ss.Value, err))
+	}
+	return chn
+}
+
+// relLinks returns the relative selector argument of :has parsing it if the
+// SimpleSelector wasn't constructed by the parser.
+func (ss SimpleSelector) relLinks() []Link {
+	if ss.rel != nil {
+		return ss.rel
+	}
+	ls, err := parseRelative(ss.Arg)
+	if err != nil {
+		panic(fmt.Errorf("Invalid argument to PseudoClass %s: %s", ss.Value, err))
+	}
+	return ls
+}
+
+// matchRelative returns true if following the links from n finds any nodes.
+func matchRelative(ls []Link, n *html.Node) bool {
+	found := []*html.Node{n}
+	for _, l := range ls {
+		set := make(map[*html.Node]struct{})
+		var next []*html.Node
+		for _, n := range found {
+			for _, n1 := range l.Find(n) {
+				if _, ok := set[n1]; !ok {
+					next = append(next, n1)
+					set[n1] = struct{}{}
+				}
+			}
+		}
+		if len(next) == 0 {
+			return false
+		}
+		found = next
+	}
+	return true
+}
+
+// matchLang returns true if the language of n, as set by the lang attribute
+// on it or its nearest ancestor with one, is lang or starts with lang
+// followed by a dash.
+func matchLang(lang string, n *html.Node) bool {
+	lang = strings.ToLower(lang)
+	for ; n != nil; n = n.Parent {
+		for _, a := range n.Attr {
+			if strings.ToLower(a.Key) == "lang" {
+				val := strings.ToLower(a.Val)
+				return val == lang || strings.HasPrefix(val, lang+"-")
+			}
+		}
+	}
+	return false
+}
+
+// unquote strips the quotes from a quoted PseudoClass argument.
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// matchContains returns true if the text content of n contains text. If
+// fold is true the comparison ignores case.
+func matchContains(text string, n *html.Node, fold bool) bool {
+	content := h5.TextContent(n)
+	if fold {
+		return strings.Contains(strings.ToLower(content), strings.ToLower(text))
+	}
+	return strings.Contains(content, text)
+}
+
 // Match returns true if this SimpleSelector matches this node false otherwise.
 func (ss SimpleSelector) Match(n *html.Node) bool {
 	if n == nil {
 		return false
 	}
+	if ss.Type == Universal {
+		return n.Type == html.ElementNode
+	}
 	if ss.Type == Tag {
-		return strings.ToLower(ss.Tag) == strings.ToLower(h5.Data(n))
+		return n.Type == html.ElementNode &&
+			strings.ToLower(ss.Tag) == strings.ToLower(h5.Data(n))
 	}
 	if ss.Type == PseudoClass {
 		switch ss.Value {
 		case "root":
-			return n.Parent == nil
+			return n.Type == html.ElementNode &&
+				(n.Parent == nil || n.Parent.Type == html.DocumentNode)
 		case "first-child":
 			return n.Parent != nil && n.Parent.FirstChild == n
 		case "last-child":
@@ -143,7 +264,20 @@ func (ss SimpleSelector) Match(n *html.Node) bool {
 			return n.PrevSibling == nil && n.NextSibling == nil
 		case "empty":
 			return n.FirstChild == nil
+		case "lang":
+			return matchLang(unquote(ss.Arg), n)
+		case "not":
+			return !ss.argChain().Match(n)
+		case "has":
+			return matchRelative(ss.relLinks(), n)
+		case "contains":
+			return matchContains(unquote(ss.Arg), n, false)
+		case "contains-i":
+			return matchContains(unquote(ss.Arg), n, true)
 		default:
+			if fn, ok := registeredPseudo(ss.Value); ok {
+				return fn(n, ss.Arg)
+			}
 			// TODO(jwall):
 			panic(fmt.Errorf("Can't match with PseudoClass %s", ss.Value))
 		}
@@ -169,6 +303,8 @@ func (ss SimpleSelector) Match(n *html.Node) bool {
 					return attrContains(ss.Value, &a)
 				case DashPrefix:
 					return attrDashPrefix(ss.Value, &a)
+				case Prefix, Suffix, Substring:
+					return attrSubstring(ss.AttrMatch, ss.Value, &a)
 				}
 				return true
 			}
@@ -199,6 +335,9 @@ func (ss SimpleSelector) String() string {
 	case Attr:
 		return "[" + ss.AttrName + ss.AttrMatch.String() + ss.Value + "]"
 	case PseudoClass:
+		if ss.Arg != "" {
+			return ":" + ss.Value + "(" + ss.Arg + ")"
+		}
 		return ":" + ss.Value
 	case PseudoElement:
 		return "::" + ss.Value
@@ -215,10 +354,14 @@ func (ss SimpleSelector) String() string {
 type Sequence []SimpleSelector
 
 // Find finds all Nodes that match this sequence in the tree rooted by
-// n.
+// n. The content of <template> elements is skipped.
 func (s Sequence) Find(n *html.Node) []*html.Node {
+	return s.find(n, false)
+}
+
+func (s Sequence) find(n *html.Node, includeTemplates bool) []*html.Node {
 	var found []*html.Node
-	h5.WalkNodes(n, func(n *html.Node) {
+	walk(n, includeTemplates, func(n *html.Node) {
 		if s.Match(n) {
 			found = append(found, n)
 		}
@@ -226,6 +369,24 @@ func (s Sequence) Find(n *html.Node) []*html.Node {
 	return found
 }
 
+func isTemplate(n *html.Node) bool {
+	return n.Type == html.ElementNode && strings.ToLower(h5.Data(n)) == "template"
+}
+
+// walk is like h5.WalkNodes but only descends into the inert content of
+// <template> elements if includeTemplates is true.
+func walk(n *html.Node, includeTemplates bool, f func(*html.Node)) {
+	if n != nil {
+		f(n)
+		if !includeTemplates && isTemplate(n) {
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, includeTemplates, f)
+		}
+	}
+}
+
 // Match returns true if this Sequence matches this node false otherwise.
 func (s Sequence) Match(n *html.Node) bool {
 	if n == nil {
@@ -272,17 +433,30 @@ type Link struct {
 }
 
 // Find all the nodes in a html.Node tree that match this Selector Link.
+// The content of <template> elements is skipped.
 func (l Link) Find(n *html.Node) []*html.Node {
+	return l.find(n, false)
+}
+
+func (l Link) find(n *html.Node, includeTemplates bool) []*html.Node {
 	var found []*html.Node
 	switch l.Combinator {
 	case Descendant:
-		// walk the node tree returning any nodes the sequence matches
-		h5.WalkNodes(n, func(n *html.Node) {
-			if l.Sequence.Match(n) {
-				found = append(found, n)
-			}
-		})
+		if !includeTemplates && isTemplate(n) {
+			break
+		}
+		// walk the node tree below n returning any nodes the sequence matches
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, includeTemplates, func(n *html.Node) {
+				if l.Sequence.Match(n) {
+					found = append(found, n)
+				}
+			})
+		}
 	case Child:
+		if !includeTemplates && isTemplate(n) {
+			break
+		}
 		// iterate through the children returning any nodes the sequence matches
 		for c := n.FirstChild; c != nil; c = c.NextSibling {
 			if l.Sequence.Match(c) {
@@ -323,16 +497,19 @@ type Chain struct {
 	Head Sequence
 	// The rest of the Links in the chain.
 	Tail []Link
+	// IncludeTemplates makes Find descend into the content of <template>
+	// elements. Template content is inert so it is skipped by default.
+	IncludeTemplates bool
 }
 
 // Find all the nodes in a html.Node tree that match this Selector Chain.
 func (chn *Chain) Find(n *html.Node) []*html.Node {
 	set := make(map[*html.Node]struct{})
-	found := chn.Head.Find(n)
+	found := chn.Head.find(n, chn.IncludeTemplates)
 	for _, l := range chn.Tail {
 		var interesting []*html.Node
 		for _, n := range found {
-			for _, n1 := range l.Find(n) {
+			for _, n1 := range l.find(n, chn.IncludeTemplates) {
 				if _, ok := set[n1]; !ok {
 					interesting = append(interesting, n1)
 					set[n1] = struct{}{}
@@ -344,6 +521,121 @@ func (chn *Chain) Find(n *html.Node) []*html.Node {
 	return found
 }
 
+// Iterate returns a function that yields the nodes in the tree rooted at n,
+// including n, that this Chain Matches one at a time in document order. The
+// second result is false once there are no more matches. Nodes are only
+// visited as they are asked for so the caller can stop early without walking
+// the rest of the tree, and nodes added after the last match are still
+// visited. Since it uses Match, unlike Find combinators can be satisfied by
+// the context outside n.
+func (chn *Chain) Iterate(n *html.Node) func() (*html.Node, bool) {
+	var cur *html.Node
+	started := false
+	return func() (*html.Node, bool) {
+		for {
+			if !started {
+				cur, started = n, true
+			} else {
+				cur = chn.successor(n, cur)
+			}
+			if cur == nil {
+				return nil, false
+			}
+			if chn.Match(cur) {
+				return cur, true
+			}
+		}
+	}
+}
+
+// FindRange returns the matches Iterate yields for the tree rooted at n
+// whose zero based position in document order is in [start, end). It stops
+// walking the tree once it has the match before end. A negative start is
+// treated as 0 and an end past the last match as the number of matches. If
+// end isn't after start nothing is returned.
+func (chn *Chain) FindRange(n *html.Node, start, end int) []*html.Node {
+	if start < 0 {
+		start = 0
+	}
+	var found []*html.Node
+	next := chn.Iterate(n)
+	for i := 0; i < end; i++ {
+		m, ok := next()
+		if !ok {
+			break
+		}
+		if i >= start {
+			found = append(found, m)
+		}
+	}
+	return found
+}
+
+// successor returns the node after c in a pre-order walk of the tree rooted
+// at root or nil if c was the last one.
+func (chn *Chain) successor(root, c *html.Node) *html.Node {
+	if c.FirstChild != nil && (chn.IncludeTemplates || !isTemplate(c)) {
+		return c.FirstChild
+	}
+	for ; c != nil && c != root; c = c.Parent {
+		if c.NextSibling != nil {
+			return c.NextSibling
+		}
+	}
+	return nil
+}
+
+// Match returns true if n matches this Selector Chain. The combinators are
+// checked against the ancestors and siblings of n wherever they are in the
+// tree.
+func (chn *Chain) Match(n *html.Node) bool {
+	if chn == nil {
+		return false
+	}
+	return matchChain(chn.Head, chn.Tail, n)
+}
+
+// matchChain matches the chain right to left, backtracking through the
+// candidates each combinator allows.
+func matchChain(head Sequence, tail []Link, n *html.Node) bool {
+	if n == nil {
+		return false
+	}
+	if len(tail) == 0 {
+		return head.Match(n)
+	}
+	l := tail[len(tail)-1]
+	if !l.Sequence.Match(n) {
+		return false
+	}
+	rest := tail[:len(tail)-1]
+	switch l.Combinator {
+	case Descendant:
+		for p := n.Parent; p != nil; p = p.Parent {
+			if matchChain(head, rest, p) {
+				return true
+			}
+		}
+	case Child:
+		return matchChain(head, rest, n.Parent)
+	case AdjacentSibling:
+		return matchChain(head, rest, n.PrevSibling) ||
+			matchChain(head, rest, n.NextSibling)
+	case Sibling:
+		for s := n.PrevSibling; s != nil; s = s.PrevSibling {
+			if matchChain(head, rest, s) {
+				return true
+			}
+		}
+		for s := n.NextSibling; s != nil; s = s.NextSibling {
+			if matchChain(head, rest, s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (chn *Chain) String() string {
 	if chn == nil {
 		return ""
@@ -22,6 +22,20 @@ var chains = []string{
 	"ul.foo.bar:first-child::first-line>a.link",
 	"ul.foo.bar:first-child::first-line>a.link+br.quux",
 	"ul.foo.bar:first-child::first-line>a.link+br.quux~hr.sep div",
+	// functional pseudo classes
+	"p:lang(en)",
+	"li:first-child:not(.skip)",
+	"li:not(:not(a.b))",
+	"li:has(> ul)",
+	"li:has(a.b)",
+	"p:contains(foo)",
+	"p:contains-i(foo)",
+	// multiple attribute matchers
+	"input[type=text][required]",
+	// substring attribute matchers
+	"a[href^=https]",
+	"a[href$=.pdf]",
+	"a[href*=example]",
 }
 
 func TestSelectorString(t *testing.T) {
@@ -47,3 +61,45 @@ func TestSelectorString(t *testing.T) {
 		t.Errorf("Next byte was not %c", b)
 	}
 }
+
+func TestSelectorErrors(t *testing.T) {
+	for _, chn := range []string{
+		"li:first-child:",
+		"li:first-child:not(",
+		"li:first-child:bogus",
+		"li:not()",
+		"li:not(>a)",
+		"li:first-child(2)",
+		"li:lang",
+		"li:has(>)",
+		"li:has(> > ul)",
+		"a.",
+		"a[b=\"c\"d]",
+	} {
+		if _, err := Selector(chn); err == nil {
+			t.Errorf("Parsing %q didn't return an error", chn)
+		}
+	}
+}
+
+func TestSelectors(t *testing.T) {
+	sels := []string{"a.foo", "li:bogus", "div>p", "a.", "ul li"}
+	chns, errs := Selectors(sels)
+	if len(chns) != len(sels) || len(errs) != len(sels) {
+		t.Fatalf("Got %d chains and %d errors for %d selectors",
+			len(chns), len(errs), len(sels))
+	}
+	for i, bad := range []bool{false, true, false, true, false} {
+		if bad {
+			if errs[i] == nil || chns[i] != nil {
+				t.Errorf("Expected an error for %q", sels[i])
+			}
+			continue
+		}
+		if errs[i] != nil {
+			t.Errorf("Unexpected error for %q: %s", sels[i], errs[i])
+		} else if chns[i].String() != sels[i] {
+			t.Errorf("Got %q Expected %q", chns[i].String(), sels[i])
+		}
+	}
+}
@@ -3,7 +3,10 @@ package selector
 import (
 	"code.google.com/p/go-html-transform/h5"
 
+	"bytes"
 	"code.google.com/p/go.net/html"
+	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -25,6 +28,18 @@ func partials(s string) []*html.Node {
 }
 
 var matchers = []testSpec{
+	testSpec{
+		"*",
+		partial("<a></a>"),
+		h5.Text("a"),
+		nil,
+	},
+	testSpec{
+		"p",
+		partial("<p></p>"),
+		h5.Text("p"),
+		nil,
+	},
 	testSpec{
 		"a",
 		partial("<a></a>"),
@@ -73,6 +88,84 @@ var matchers = []testSpec{
 		partial("<a class=\"baz foo0 bar\"></a>"),
 		nil,
 	},
+	testSpec{
+		"a[href^=https]",
+		partial("<a href=\"https://example.com/\"></a>"),
+		partial("<a href=\"http://example.com/\"></a>"),
+		nil,
+	},
+	testSpec{
+		"a[href$=\".pdf\"]",
+		partial("<a href=\"/files/report.pdf\"></a>"),
+		partial("<a href=\"/files/report.pdf.html\"></a>"),
+		nil,
+	},
+	testSpec{
+		"a[href*=example]",
+		partial("<a href=\"https://www.example.com/\"></a>"),
+		partial("<a href=\"https://www.exam.com/\"></a>"),
+		nil,
+	},
+	testSpec{
+		"a:lang(en)",
+		partial("<a lang=\"en-US\"></a>"),
+		partial("<a lang=\"eng\"></a>"),
+		nil,
+	},
+	testSpec{
+		"a:lang( en )",
+		partial("<a lang=\"en-US\"></a>"),
+		partial("<a lang=\"eng\"></a>"),
+		nil,
+	},
+	testSpec{
+		"p:contains(Hello)",
+		partial("<p>Hello <b>world</b></p>"),
+		partial("<p>hello world</p>"),
+		nil,
+	},
+	testSpec{
+		"p:contains-i(hello)",
+		partial("<p>Hello <b>world</b></p>"),
+		partial("<p>Goodbye</p>"),
+		nil,
+	},
+	testSpec{
+		"p:contains-i(\"WORLD\")",
+		partial("<p>Hello <b>world</b></p>"),
+		partial("<p>Hello</p>"),
+		nil,
+	},
+	testSpec{
+		"a:not(.foo)",
+		partial("<a class=\"bar\"></a>"),
+		partial("<a class=\"foo bar\"></a>"),
+		nil,
+	},
+	testSpec{
+		"input[type=text][required]",
+		partial("<input type=\"text\" required>"),
+		partial("<input type=\"text\">"),
+		nil,
+	},
+	testSpec{
+		"input[type=text][required]",
+		partial("<input type=\"text\" required>"),
+		partial("<input type=\"checkbox\" required>"),
+		nil,
+	},
+	testSpec{
+		"[type=\"text\"][required]",
+		partial("<input required type=\"text\">"),
+		partial("<input required type=\"textarea\">"),
+		nil,
+	},
+	testSpec{
+		"a[title='a ] b']",
+		partial("<a title=\"a ] b\"></a>"),
+		partial("<a title=\"a\"></a>"),
+		nil,
+	},
 }
 
 var finders = []testSpec{
@@ -142,6 +235,12 @@ var finders = []testSpec{
 		nil,
 		partials("<span>foo</span><span>bar</span>"),
 	},
+	testSpec{
+		"div div",
+		partial("<div><div>foo</div></div>"),
+		nil,
+		partials("<div>foo</div>"),
+	},
 	testSpec{
 		":empty",
 		partial("<div><div></div></div>"),
@@ -160,6 +259,36 @@ var finders = []testSpec{
 		nil,
 		partials("<b>baz</b>"),
 	},
+	testSpec{
+		"span:lang(fr)",
+		partial("<div lang=\"fr\"><span>foo</span><p lang=\"en\"><span>bar</span></p></div>"),
+		nil,
+		partials("<span>foo</span>"),
+	},
+	testSpec{
+		"li:first-child:not(.skip)",
+		partial("<div><ul><li>a</li><li>b</li></ul><ul><li class=\"skip\">c</li><li>d</li></ul></div>"),
+		nil,
+		partials("<li>a</li>"),
+	},
+	testSpec{
+		"li:has(> ul)",
+		partial("<ul><li>a<ul><li>b</li></ul></li><li>c<div><ul><li>d</li></ul></div></li></ul>"),
+		nil,
+		partials("<li>a<ul><li>b</li></ul></li>"),
+	},
+	testSpec{
+		"li:has(ul)",
+		partial("<ul><li>a<ul><li>b</li></ul></li><li>c<div><ul><li>d</li></ul></div></li></ul>"),
+		nil,
+		partials("<li>a<ul><li>b</li></ul></li><li>c<div><ul><li>d</li></ul></div></li>"),
+	},
+	testSpec{
+		"li:has(> div ul)",
+		partial("<ul><li>a<ul><li>b</li></ul></li><li>c<div><ul><li>d</li></ul></div></li></ul>"),
+		nil,
+		partials("<li>c<div><ul><li>d</li></ul></div></li>"),
+	},
 	testSpec{
 		"a:only-child",
 		partial("<div><a>foo</a></div>"),
@@ -186,6 +315,50 @@ func TestSelectorFind(t *testing.T) {
 	}
 }
 
+func TestChainMatch(t *testing.T) {
+	for _, spec := range finders {
+		chn, err := Selector(spec.s)
+		if err != nil {
+			t.Errorf("Error parsing selector %q", err)
+		}
+		found := make(map[*html.Node]bool)
+		for _, n := range chn.Find(spec.n) {
+			found[n] = true
+		}
+		h5.WalkNodes(spec.n, func(n *html.Node) {
+			if n == spec.n {
+				// Find ignores the context outside spec.n
+				return
+			}
+			if chn.Match(n) != found[n] {
+				t.Errorf("%q Match(%q) was %v but Find disagreed",
+					chn, h5.RenderNodesToString([]*html.Node{n}), chn.Match(n))
+			}
+		})
+	}
+}
+
+func TestSelectorFindTemplates(t *testing.T) {
+	n := partial("<div><p>foo</p><template><p>bar</p><span>baz</span></template></div>")
+	for _, sel := range []string{"p", "div p", "template>p", "template span"} {
+		chn, err := Selector(sel)
+		if err != nil {
+			t.Fatalf("Error parsing selector %q", err)
+		}
+		ns := chn.Find(n)
+		for _, n := range ns {
+			if h5.RenderNodesToString([]*html.Node{n}) != "<p>foo</p>" {
+				t.Errorf("%q matched %q inside a template", sel,
+					h5.RenderNodesToString([]*html.Node{n}))
+			}
+		}
+		chn.IncludeTemplates = true
+		if len(chn.Find(n)) <= len(ns) {
+			t.Errorf("%q didn't find template content with IncludeTemplates", sel)
+		}
+	}
+}
+
 func TestSelectorMatch(t *testing.T) {
 	for _, spec := range matchers {
 		chn, err := Selector(spec.s)
@@ -202,3 +375,109 @@ func TestSelectorMatch(t *testing.T) {
 		}
 	}
 }
+
+func TestSelectorRoot(t *testing.T) {
+	tree, _ := h5.NewFromString(
+		"<html><body><header>foo</header><div><header>bar</header></div></body></html>")
+	for sel, expected := range map[string]string{
+		":root":                 "<html><head></head><body><header>foo</header><div><header>bar</header></div></body></html>",
+		":root > body > header": "<header>foo</header>",
+		"html:root":             "<html><head></head><body><header>foo</header><div><header>bar</header></div></body></html>",
+		"body:root":             "",
+		":root header":          "<header>foo</header><header>bar</header>",
+	} {
+		chn, err := Selector(sel)
+		if err != nil {
+			t.Fatalf("Error parsing selector %q", err)
+		}
+		ns := chn.Find(tree.Top())
+		if h5.RenderNodesToString(ns) != expected {
+			t.Errorf("%q Got: %q Expected: %q", sel, h5.RenderNodesToString(ns), expected)
+		}
+	}
+}
+
+func TestChainIterate(t *testing.T) {
+	for _, spec := range finders {
+		chn, err := Selector(spec.s)
+		if err != nil {
+			t.Errorf("Error parsing selector %q", err)
+		}
+		found := make(map[*html.Node]bool)
+		for _, n := range chn.Find(spec.n) {
+			found[n] = true
+		}
+		count := 0
+		next := chn.Iterate(spec.n)
+		for n, ok := next(); ok; n, ok = next() {
+			if !found[n] {
+				t.Errorf("%q Iterate yielded %q but Find didn't", chn,
+					h5.RenderNodesToString([]*html.Node{n}))
+			}
+			count++
+		}
+		if count != len(found) {
+			t.Errorf("%q Iterate found %d nodes but Find found %d",
+				chn, count, len(found))
+		}
+	}
+}
+
+func TestChainIterateLazy(t *testing.T) {
+	n := partial("<div><p>a</p><p>b</p><template><p>c</p></template></div>")
+	chn, _ := Selector("p")
+	next := chn.Iterate(n)
+	first, ok := next()
+	if !ok || h5.TextContent(first) != "a" {
+		t.Fatalf("Expected the first p got %v", first)
+	}
+	// Nodes added after the iterator started are still visited.
+	n.AppendChild(partial("<p>d</p>"))
+	var texts []string
+	for p, ok := next(); ok; p, ok = next() {
+		texts = append(texts, h5.TextContent(p))
+	}
+	if got := strings.Join(texts, ","); got != "b,d" {
+		t.Errorf("Got: %q Expected: %q", got, "b,d")
+	}
+	chn.IncludeTemplates = true
+	texts = nil
+	next = chn.Iterate(n)
+	for p, ok := next(); ok; p, ok = next() {
+		texts = append(texts, h5.TextContent(p))
+	}
+	if got := strings.Join(texts, ","); got != "a,b,c,d" {
+		t.Errorf("Got: %q Expected: %q", got, "a,b,c,d")
+	}
+}
+
+func TestChainFindRange(t *testing.T) {
+	var b bytes.Buffer
+	b.WriteString("<ul>")
+	for i := 0; i < 30; i++ {
+		fmt.Fprintf(&b, "<li>%d</li>", i)
+	}
+	b.WriteString("</ul>")
+	n := partial(b.String())
+	chn, _ := Selector("li")
+	for _, spec := range []struct {
+		start, end int
+		expected   string
+	}{
+		{10, 20, "10,11,12,13,14,15,16,17,18,19"},
+		{-5, 3, "0,1,2"},
+		{27, 100, "27,28,29"},
+		{40, 50, ""},
+		{5, 5, ""},
+		{5, 2, ""},
+	} {
+		var texts []string
+		for _, li := range chn.FindRange(n, spec.start, spec.end) {
+			texts = append(texts, h5.TextContent(li))
+		}
+		if got := strings.Join(texts, ","); got != spec.expected {
+			t.Errorf("FindRange(%d, %d) Got: %q Expected: %q",
+				spec.start, spec.end, got, spec.expected)
+		}
+	}
+}
@@ -28,6 +28,24 @@ func Selector(sel string) (*Chain, error) {
 	return SelectorFromScanner(strings.NewReader(sel))
 }
 
+// Selectors parses a batch of strings into Chains so that a whole set of
+// rules can be validated up front. The returned slices are aligned with sels.
+// A selector that fails to parse has a nil Chain and its error at the same
+// index; every other index has a nil error.
+func Selectors(sels []string) ([]*Chain, []error) {
+	chns := make([]*Chain, len(sels))
+	errs := make([]error, len(sels))
+	for i, sel := range sels {
+		chn, err := Selector(sel)
+		if err != nil && err != EOS {
+			errs[i] = err
+			continue
+		}
+		chns[i] = chn
+	}
+	return chns, errs
+}
+
 func consumeValue(rdr io.ByteScanner) ([]byte, error) {
 	bs := []byte{}
 	for c, err := rdr.ReadByte(); err != io.EOF; c, err = rdr.ReadByte() {
@@ -38,7 +56,7 @@ func consumeValue(rdr io.ByteScanner) ([]byte, error) {
 		case '{':
 			rdr.UnreadByte()
 			return bs, EOS
-		case '>', '+', '~', ' ', '\t', '\n', '\f', ',', '.', '#', '[', ':':
+		case '>', '+', '~', ' ', '\t', '\n', '\f', ',', '.', '#', '[', ':', '(':
 			rdr.UnreadByte()
 			return bs, nil
 		default:
@@ -59,6 +77,9 @@ func parseSimpleTag(rdr io.ByteScanner, sel *SimpleSelector) error {
 
 func parseSimpleSelector(rdr io.ByteScanner, sel *SimpleSelector) error {
 	b, err := rdr.ReadByte()
+	if err == io.EOF {
+		return fmt.Errorf("Unexpected end of selector")
+	}
 	if err != nil && err != EOS {
 		return err
 	}
@@ -72,9 +93,123 @@ func parseSimpleSelector(rdr io.ByteScanner, sel *SimpleSelector) error {
 		bs = bs[1:]
 	}
 	sel.Value = string(bs)
+	if sel.Type == PseudoClass && err == nil {
+		if c, err := rdr.ReadByte(); err == nil {
+			if c != '(' {
+				rdr.UnreadByte()
+				return nil
+			}
+			arg, err := consumeArg(rdr)
+			if err != nil {
+				return err
+			}
+			sel.Arg = string(arg)
+		}
+	}
 	return err
 }
 
+// pseudoClassArgs records the PseudoClasses we know how to match and whether
+// they take an argument.
+var pseudoClassArgs = map[string]bool{
+	"root":        false,
+	"first-child": false,
+	"last-child":  false,
+	"only-child":  false,
+	"empty":       false,
+	"lang":        true,
+	"not":         true,
+	"has":         true,
+	"contains":    true,
+	"contains-i":  true,
+}
+
+// checkPseudoClass validates a parsed PseudoClass and parses its argument
+// if it is a selector.
+func checkPseudoClass(sel *SimpleSelector) error {
+	if sel.Type != PseudoClass {
+		return nil
+	}
+	if sel.Value == "" {
+		return fmt.Errorf("Empty PseudoClass name")
+	}
+	takesArg, ok := pseudoClassArgs[sel.Value]
+	if !ok {
+		if _, ok := registeredPseudo(sel.Value); ok {
+			// Custom PseudoClasses check their own argument.
+			return nil
+		}
+		return fmt.Errorf("Unknown PseudoClass %s", sel.Value)
+	}
+	if takesArg && strings.TrimSpace(sel.Arg) == "" {
+		return fmt.Errorf("PseudoClass %s requires an argument", sel.Value)
+	}
+	if !takesArg && sel.Arg != "" {
+		return fmt.Errorf("PseudoClass %s doesn't take an argument", sel.Value)
+	}
+	if sel.Value == "not" {
+		chn, err := Selector(strings.TrimSpace(sel.Arg))
+		if err != nil {
+			return fmt.Errorf("Invalid argument to PseudoClass not: %s", err)
+		}
+		sel.sub = chn
+	}
+	if sel.Value == "has" {
+		ls, err := parseRelative(sel.Arg)
+		if err != nil {
+			return fmt.Errorf("Invalid argument to PseudoClass has: %s", err)
+		}
+		sel.rel = ls
+	}
+	return nil
+}
+
+// parseRelative parses a selector relative to an element such as the
+// "> ul" in li:has(> ul). Without a leading combinator the selector is
+// relative to the element's descendants.
+func parseRelative(sel string) ([]Link, error) {
+	sel = strings.TrimSpace(sel)
+	first := Link{Combinator: Descendant}
+	if sel != "" {
+		if c, ok := combinatorMap[sel[0]]; ok {
+			first.Combinator = c
+			sel = strings.TrimSpace(sel[1:])
+		}
+	}
+	chn, err := Selector(sel)
+	if err != nil {
+		return nil, err
+	}
+	if len(chn.Head) == 0 {
+		return nil, fmt.Errorf("Empty relative selector")
+	}
+	first.Sequence = chn.Head
+	return append([]Link{first}, chn.Tail...), nil
+}
+
+// consumeArg consumes the argument of a functional pseudo class up to the
+// matching ')'.
+func consumeArg(rdr io.ByteScanner) ([]byte, error) {
+	bs := []byte{}
+	depth := 1
+	for c, err := rdr.ReadByte(); err != io.EOF; c, err = rdr.ReadByte() {
+		if err != nil {
+			return nil, err
+		}
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return bs, nil
+			}
+		}
+		bs = append(bs, c)
+	}
+	return nil, fmt.Errorf("Didn't close PseudoClass argument")
+}
+
 func parseSimpleAttr(rdr io.ByteScanner, sel *SimpleSelector) error {
 	var name []byte
 	var value []byte
@@ -89,11 +224,18 @@ func parseSimpleAttr(rdr io.ByteScanner, sel *SimpleSelector) error {
 			sel.Value = string(value)
 			return nil
 		case '=':
-			if c1 == '~' {
+			switch c1 {
+			case '~':
 				sel.AttrMatch = Contains
-			} else if c1 == '|' {
+			case '|':
 				sel.AttrMatch = DashPrefix
-			} else {
+			case '^':
+				sel.AttrMatch = Prefix
+			case '$':
+				sel.AttrMatch = Suffix
+			case '*':
+				sel.AttrMatch = Substring
+			default:
 				sel.AttrMatch = Exactly
 			}
 		case '{':
@@ -101,7 +243,22 @@ func parseSimpleAttr(rdr io.ByteScanner, sel *SimpleSelector) error {
 			return EOS
 		case '~':
 		case '|':
-		// TODO(jwall): Substring matchers
+		case '^', '$', '*':
+			if sel.AttrMatch != Presence {
+				value = append(value, c2)
+			}
+		case '"', '\'':
+			if sel.AttrMatch == Presence || len(value) > 0 {
+				return fmt.Errorf("Unexpected quote in Attribute Matcher")
+			}
+			quoted, err := consumeQuoted(rdr, c2)
+			if err != nil {
+				return err
+			}
+			value = append(value, quoted...)
+			if err := consumeUntilClose(rdr); err != nil {
+				return err
+			}
 		default:
 			if sel.AttrMatch == Presence {
 				name = append(name, c2)
@@ -114,6 +271,41 @@ func parseSimpleAttr(rdr io.ByteScanner, sel *SimpleSelector) error {
 	return fmt.Errorf("Didn't close Attribute Matcher")
 }
 
+// consumeUntilClose skips the whitespace between a quoted value and the
+// closing ] of an Attribute Matcher, leaving the ] to be read next.
+func consumeUntilClose(rdr io.ByteScanner) error {
+	for c, err := rdr.ReadByte(); err != io.EOF; c, err = rdr.ReadByte() {
+		if err != nil {
+			return err
+		}
+		switch c {
+		case ' ', '\t', '\n', '\r', '\f':
+		case ']':
+			rdr.UnreadByte()
+			return nil
+		default:
+			return fmt.Errorf("Unexpected character after quoted value in Attribute Matcher")
+		}
+	}
+	return fmt.Errorf("Didn't close Attribute Matcher")
+}
+
+// consumeQuoted consumes the rest of a string quoted by q returning its
+// contents.
+func consumeQuoted(rdr io.ByteScanner, q byte) ([]byte, error) {
+	bs := []byte{}
+	for c, err := rdr.ReadByte(); err != io.EOF; c, err = rdr.ReadByte() {
+		if err != nil {
+			return nil, err
+		}
+		if c == q {
+			return bs, nil
+		}
+		bs = append(bs, c)
+	}
+	return nil, fmt.Errorf("Unterminated string in Attribute Matcher")
+}
+
 func parseSequence(rdr io.ByteScanner) (Sequence, error) {
 	seq := []SimpleSelector{}
 	rdr.UnreadByte()
@@ -141,6 +333,9 @@ func parseSequence(rdr io.ByteScanner) (Sequence, error) {
 			if err := parseSimpleSelector(rdr, &sel); err != nil {
 				return nil, err
 			}
+			if err := checkPseudoClass(&sel); err != nil {
+				return nil, err
+			}
 			seq = append(seq, sel)
 		case '[':
 			sel := SimpleSelector{Type: Attr}
@@ -0,0 +1,51 @@
+package selector
+
+import (
+	"fmt"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// PseudoFunc reports whether n matches a custom PseudoClass. arg is the
+// text between the parentheses of a functional PseudoClass such as
+// :external(example.com) or "" if there aren't any.
+type PseudoFunc func(n *html.Node, arg string) bool
+
+var (
+	pseudoMu sync.RWMutex
+	pseudos  = map[string]PseudoFunc{}
+)
+
+// RegisterPseudo makes a custom PseudoClass available to selectors parsed
+// after it is called. It is safe to call from multiple goroutines. It panics
+// if name is empty, is one of the built in PseudoClasses or has already been
+// registered. Selectors using a PseudoClass that is neither built in nor
+// registered fail to parse.
+//
+//	RegisterPseudo("external", func(n *html.Node, arg string) bool {
+//		...
+//	})
+//	chn, err := Selector("a:external")
+func RegisterPseudo(name string, fn PseudoFunc) {
+	if name == "" || fn == nil {
+		panic("selector: RegisterPseudo needs a name and a func")
+	}
+	if _, ok := pseudoClassArgs[name]; ok {
+		panic(fmt.Sprintf("selector: can't register built in PseudoClass %s", name))
+	}
+	pseudoMu.Lock()
+	defer pseudoMu.Unlock()
+	if _, ok := pseudos[name]; ok {
+		panic(fmt.Sprintf("selector: PseudoClass %s is already registered", name))
+	}
+	pseudos[name] = fn
+}
+
+// registeredPseudo returns the custom PseudoClass registered as name.
+func registeredPseudo(name string) (PseudoFunc, bool) {
+	pseudoMu.RLock()
+	defer pseudoMu.RUnlock()
+	fn, ok := pseudos[name]
+	return fn, ok
+}
@@ -0,0 +1,62 @@
+package selector
+
+import (
+	"strings"
+	"testing"
+
+	"code.google.com/p/go-html-transform/h5"
+	"code.google.com/p/go.net/html"
+)
+
+// unregisterPseudo removes a PseudoClass registered by a test so the test
+// can be run again.
+func unregisterPseudo(name string) {
+	pseudoMu.Lock()
+	defer pseudoMu.Unlock()
+	delete(pseudos, name)
+}
+
+func TestRegisterPseudo(t *testing.T) {
+	if _, err := Selector("a:test-external"); err == nil {
+		t.Error("Expected an error for an unregistered PseudoClass")
+	}
+	defer unregisterPseudo("test-external")
+	RegisterPseudo("test-external", func(n *html.Node, arg string) bool {
+		for _, a := range n.Attr {
+			if a.Key == "href" {
+				return strings.HasPrefix(a.Val, "http") &&
+					(arg == "" || !strings.Contains(a.Val, "//"+arg))
+			}
+		}
+		return false
+	})
+	n := partial("<div><a href=\"/home\">a</a><a href=\"http://example.com/\">b</a>" +
+		"<a href=\"http://other.org/\">c</a></div>")
+	for sel, expected := range map[string]string{
+		"a:test-external":              "bc",
+		"a:test-external(example.com)": "c",
+		"a:not(:test-external)":        "a",
+	} {
+		chn, err := Selector(sel)
+		if err != nil {
+			t.Fatalf("Error parsing selector %q", err)
+		}
+		var got string
+		for _, m := range chn.Find(n) {
+			got += h5.TextContent(m)
+		}
+		if got != expected {
+			t.Errorf("%q Got: %q Expected: %q", sel, got, expected)
+		}
+	}
+	for _, name := range []string{"test-external", "first-child", ""} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected registering %q to panic", name)
+				}
+			}()
+			RegisterPseudo(name, func(*html.Node, string) bool { return true })
+		}()
+	}
+}
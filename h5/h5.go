@@ -53,6 +53,22 @@ func RenderNodesToString(ns []*html.Node) string {
 	return string(buf.Bytes())
 }
 
+// countingWriter discards what is written to it keeping only the count.
+type countingWriter int
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	*c += countingWriter(len(p))
+	return len(p), nil
+}
+
+// SerializedSize returns the length in bytes of the rendered form of n
+// without keeping the rendered html in memory.
+func SerializedSize(n *html.Node) int {
+	var c countingWriter
+	html.Render(&c, n)
+	return int(c)
+}
+
 // Construct a new h5 parser from a string
 func NewFromString(s string) (*Tree, error) {
 	return New(strings.NewReader(s))
@@ -75,5 +91,5 @@ func New(r io.Reader) (*Tree, error) {
 	if n == nil {
 		return nil, fmt.Errorf("Error parsing html from reader")
 	}
-	return &Tree{n}, nil
+	return &Tree{n: n}, nil
 }
@@ -56,6 +56,61 @@ func TestNodeWalk(t *testing.T) {
 	assertEqual(t, ns[3:], []string{"body", "a", "foo", "div", "bar"})
 }
 
+func TestTextContent(t *testing.T) {
+	tree, err := NewFromString(
+		"<html><body><p>foo <b>bar</b><!-- baz --></p>quux</body></html>")
+	assertOrDie(t, err == nil, "error while parsing string: %s", err)
+	assertEqual(t, TextContent(tree.Top()), "foo barquux")
+}
+
+func TestEqual(t *testing.T) {
+	a, _ := PartialFromString("<a href=\"/\" id=\"x\"><b>foo</b></a>")
+	b, _ := PartialFromString("<a id=\"x\" href=\"/\"><b>foo</b></a>")
+	c, _ := PartialFromString("<a id=\"x\" href=\"/\"><b>bar</b></a>")
+	d, _ := PartialFromString("<a id=\"x\" href=\"/\"><b>foo</b>!</a>")
+	assertTrue(t, Equal(a[0], b[0]), "attribute order made nodes unequal")
+	assertTrue(t, !Equal(a[0], c[0]), "nodes with different text were equal")
+	assertTrue(t, !Equal(a[0], d[0]), "nodes with different children were equal")
+}
+
+func TestCommonAncestor(t *testing.T) {
+	b := Element("b", nil, Text("b"))
+	i := Element("i", nil, Text("i"))
+	p := Element("p", nil, b)
+	div := Div("", nil, p, Element("p", nil, i))
+	assertTrue(t, CommonAncestor(b, i) == div, "common ancestor of b and i isn't the div")
+	assertTrue(t, CommonAncestor(b.FirstChild, b, p) == p, "common ancestor of b and p isn't the p")
+	assertTrue(t, CommonAncestor(i) == i, "common ancestor of i alone isn't i")
+	assertTrue(t, CommonAncestor(i, Text("detached")) == nil, "disjoint nodes have a common ancestor")
+	assertTrue(t, CommonAncestor() == nil, "no nodes have a common ancestor")
+}
+
+func TestSplitAt(t *testing.T) {
+	cs := []*html.Node{Text("a"), Element("b", nil), Text("c"), Element("i", nil), Text("e")}
+	div := Div("d", []string{"x"}, cs...)
+	body := Element("body", nil, div, Element("hr", nil))
+	second := SplitAt(div, cs[2])
+	assertEqual(t, RenderNodesToString([]*html.Node{body}),
+		"<body><div id=\"d\" class=\"x\">a<b></b></div>"+
+			"<div id=\"d\" class=\"x\">c<i></i>e</div><hr/></body>")
+	assertTrue(t, second.Parent == body && second.PrevSibling == div, "second isn't after div")
+	assertTrue(t, cs[2].Parent == second && cs[2].PrevSibling == nil, "c isn't second's first child")
+	assertTrue(t, div.LastChild == cs[1] && cs[1].NextSibling == nil, "b isn't div's last child")
+	assertTrue(t, SplitAt(div, cs[3]) == nil, "split at a child of another node")
+	first := SplitAt(second, cs[2])
+	assertEqual(t, RenderNodesToString([]*html.Node{second, first}),
+		"<div id=\"d\" class=\"x\"></div><div id=\"d\" class=\"x\">c<i></i>e</div>")
+}
+
+func TestIsVoidElement(t *testing.T) {
+	for _, tag := range []string{"br", "IMG", "input", "meta", "wbr"} {
+		assertTrue(t, IsVoidElement(tag), "%s isn't void", tag)
+	}
+	for _, tag := range []string{"p", "div", "textarea", ""} {
+		assertTrue(t, !IsVoidElement(tag), "%s is void", tag)
+	}
+}
+
 //func TestSnippet(t *testing.T) {
 //	p, err := NewParserFromString("<a></a><b>")
 //	assertOrDie(t, err == nil, "we errored while parsing snippet %s", err)
@@ -63,3 +118,50 @@ func TestNodeWalk(t *testing.T) {
 //		t, p.Top != nil, "We didn't get a node tree back while parsing snippet")
 //	assertEqual(t, p.Tree()).String(), "<a></a><b>")
 //}
+
+func TestSerializedSize(t *testing.T) {
+	tree, err := NewFromString("<html><head><title>a &amp; b</title></head>" +
+		"<body><p class=\"x\">caf\u00e9<br><script>1 < 2</script></p></body></html>")
+	assertOrDie(t, err == nil, "error while parsing string: %s", err)
+	if got, expected := SerializedSize(tree.Top()), len(tree.String()); got != expected {
+		t.Errorf("SerializedSize was %d but the rendered length is %d", got, expected)
+	}
+}
+
+func TestSetBareBooleans(t *testing.T) {
+	tree, err := NewFromString("<html><body><input disabled=\"\" value=\"\">" +
+		"<details open=\"\"></details></body></html>")
+	assertOrDie(t, err == nil, "error while parsing string: %s", err)
+	tree.SetBareBooleans(true)
+	assertEqual(t, tree.String(), "<html><head></head><body><input disabled value=\"\"/>"+
+		"<details open></details></body></html>")
+	tree.SetBareBooleans(false)
+	assertEqual(t, tree.String(), "<html><head></head><body><input disabled=\"\" value=\"\"/>"+
+		"<details open=\"\"></details></body></html>")
+}
+
+func TestCloneFiltered(t *testing.T) {
+	tree, _ := NewFromString("<html><body><article><h1>t</h1>" +
+		"<aside>ad <b>x</b></aside><p>body</p></article><aside>nav</aside></body></html>")
+	body := tree.Top().FirstChild.LastChild
+	noAside := func(n *html.Node) bool { return Data(n) != "aside" }
+	clone := CloneFiltered(body, noAside)
+	assertEqual(t, RenderNodesToString([]*html.Node{clone}),
+		"<body><article><h1>t</h1><p>body</p></article></body>")
+	assertTrue(t, clone.Parent == nil, "clone has a parent")
+	article := clone.FirstChild
+	assertTrue(t, article.Parent == clone && article.NextSibling == nil, "article isn't clone's only child")
+	assertTrue(t, article.LastChild.Parent == article && article.LastChild.PrevSibling == article.FirstChild,
+		"p isn't article's second child")
+	assertEqual(t, RenderNodesToString([]*html.Node{body}),
+		"<body><article><h1>t</h1><aside>ad <b>x</b></aside><p>body</p></article><aside>nav</aside></body>")
+	assertTrue(t, CloneFiltered(body.LastChild, noAside) == nil, "clone of a dropped node")
+
+	tree, _ = NewFromString("<html><body><svg><title>t</title><circle r=\"1\"></circle></svg></body></html>")
+	svg := tree.Top().FirstChild.LastChild.FirstChild
+	clone = CloneFiltered(svg, func(n *html.Node) bool { return Data(n) != "title" })
+	assertEqual(t, clone.Namespace, "svg")
+	assertEqual(t, clone.FirstChild.Namespace, "svg")
+	assertTrue(t, clone.FirstChild == clone.LastChild, "title wasn't dropped")
+	assertEqual(t, CloneNode(svg).Namespace, "svg")
+}
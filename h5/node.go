@@ -8,6 +8,7 @@ import (
 	exphtml "golang.org/x/net/html"
 	"golang.org/x/net/html/atom"
 
+	"bytes"
 	"io"
 	"strings"
 )
@@ -21,28 +22,142 @@ func Data(n *exphtml.Node) string {
 	return n.Data
 }
 
+// TextContent returns the concatenated text of all the TextNodes in the
+// tree rooted at n.
+func TextContent(n *exphtml.Node) string {
+	var parts []string
+	WalkNodes(n, func(n *exphtml.Node) {
+		if n.Type == exphtml.TextNode {
+			parts = append(parts, n.Data)
+		}
+	})
+	return strings.Join(parts, "")
+}
+
 // CloneNode makes a copy of a Node with all descendants.
 func CloneNode(n *exphtml.Node) *exphtml.Node {
-	clone := new(exphtml.Node)
-	clone.Type = n.Type
-	clone.DataAtom = n.DataAtom
-	clone.Data = n.Data
-	clone.Attr = make([]exphtml.Attribute, len(n.Attr))
+	return CloneFiltered(n, func(*exphtml.Node) bool { return true })
+}
+
+// CloneFiltered makes a copy of a Node and the descendants keep returns
+// true for. A node keep returns false for is left out of the copy along with
+// all of its descendants. If keep returns false for n itself CloneFiltered
+// returns nil.
+func CloneFiltered(n *exphtml.Node, keep func(*exphtml.Node) bool) *exphtml.Node {
+	if !keep(n) {
+		return nil
+	}
+	clone := &exphtml.Node{
+		Type:      n.Type,
+		DataAtom:  n.DataAtom,
+		Data:      n.Data,
+		Namespace: n.Namespace,
+		Attr:      make([]exphtml.Attribute, len(n.Attr)),
+	}
 	copy(clone.Attr, n.Attr)
 	for c := n.FirstChild; c != nil; c = c.NextSibling {
-		nc := CloneNode(c)
-		clone.AppendChild(nc)
+		if nc := CloneFiltered(c, keep); nc != nil {
+			clone.AppendChild(nc)
+		}
 	}
 	return clone
 }
 
+// Equal returns true if a and b are structurally identical. That is they
+// have the same type, data and attributes, in any order, and their children
+// are Equal.
+func Equal(a, b *exphtml.Node) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.Type != b.Type || Data(a) != Data(b) || a.Namespace != b.Namespace ||
+		len(a.Attr) != len(b.Attr) {
+		return false
+	}
+	attrs := make(map[exphtml.Attribute]int)
+	for _, attr := range a.Attr {
+		attrs[attr]++
+	}
+	for _, attr := range b.Attr {
+		if attrs[attr] == 0 {
+			return false
+		}
+		attrs[attr]--
+	}
+	ac, bc := a.FirstChild, b.FirstChild
+	for ; ac != nil && bc != nil; ac, bc = ac.NextSibling, bc.NextSibling {
+		if !Equal(ac, bc) {
+			return false
+		}
+	}
+	return ac == nil && bc == nil
+}
+
+// CommonAncestor returns the lowest node that contains all of the given
+// nodes. A node counts as containing itself so the common ancestor of a
+// node and one of its descendants is the node. It returns nil if the nodes
+// aren't in the same tree or no nodes are given.
+func CommonAncestor(ns ...*exphtml.Node) *exphtml.Node {
+	if len(ns) == 0 {
+		return nil
+	}
+	// Count how many of the nodes each ancestor contains.
+	counts := make(map[*exphtml.Node]int)
+	for _, n := range ns {
+		for p := n; p != nil; p = p.Parent {
+			counts[p]++
+		}
+	}
+	for p := ns[0]; p != nil; p = p.Parent {
+		if counts[p] == len(ns) {
+			return p
+		}
+	}
+	return nil
+}
+
+// SplitAt splits n in two at child, which must be one of its children. The
+// children before child stay in n and child and the ones after it are moved
+// to a copy of n, with the same attributes, inserted as n's next sibling. It
+// returns the copy or nil if child isn't a child of n.
+//
+//	<div>a b c d</div> split at c => <div>a b</div><div>c d</div>
+func SplitAt(n, child *exphtml.Node) *exphtml.Node {
+	if child == nil || child.Parent != n {
+		return nil
+	}
+	second := &exphtml.Node{
+		Type:      n.Type,
+		DataAtom:  n.DataAtom,
+		Data:      n.Data,
+		Namespace: n.Namespace,
+		Attr:      make([]exphtml.Attribute, len(n.Attr)),
+	}
+	copy(second.Attr, n.Attr)
+	for c := child; c != nil; {
+		next := c.NextSibling
+		n.RemoveChild(c)
+		second.AppendChild(c)
+		c = next
+	}
+	if n.Parent != nil {
+		n.Parent.InsertBefore(second, n.NextSibling)
+	}
+	return second
+}
+
 func NewTree(n *exphtml.Node) Tree {
-	return Tree{n}
+	return Tree{n: n}
 }
 
 // Tree represents an html5 Node tree.
 type Tree struct {
 	n *exphtml.Node
+	// quotes holds the attribute quote styles recorded when parsing with
+	// PreserveAttribQuotes.
+	quotes quoteStyles
+	// bareBooleans renders empty boolean attributes without a value.
+	bareBooleans bool
 }
 
 func (t Tree) Top() *exphtml.Node {
@@ -54,12 +169,23 @@ func (t Tree) Walk(f func(*exphtml.Node)) {
 	WalkNodes(t.n, f)
 }
 
+// SetBareBooleans controls whether boolean attributes with an empty value
+// are rendered bare, as in <input checked>, rather than as checked="".
+func (t *Tree) SetBareBooleans(bare bool) {
+	t.bareBooleans = bare
+}
+
 func (t Tree) Render(w io.Writer) error {
+	if t.quotes != nil || t.bareBooleans {
+		return renderQuoted(w, []*exphtml.Node{t.n}, t.quotes, t.bareBooleans)
+	}
 	return RenderNodes(w, []*exphtml.Node{t.n})
 }
 
 func (t Tree) String() string {
-	return RenderNodesToString([]*exphtml.Node{t.n})
+	buf := bytes.NewBufferString("")
+	t.Render(buf)
+	return buf.String()
 }
 
 // Walk walks a Node with all descendants applying a given function to each one.
@@ -75,7 +201,19 @@ func WalkNodes(n *exphtml.Node, f func(*exphtml.Node)) {
 // Clone clones an html5 nodetree to get a detached copy
 // the parent of the node we are cloning will not be copied.
 func (t Tree) Clone() Tree {
-	return Tree{CloneNode(t.n)}
+	return Tree{n: CloneNode(t.n), quotes: t.quotes, bareBooleans: t.bareBooleans}
+}
+
+// Reparse renders the tree and parses it again returning the new tree. The
+// new tree renders the same way as t, keeping its attribute quote styles and
+// bare booleans.
+func (t Tree) Reparse() (*Tree, error) {
+	tree, err := NewFromString(t.String())
+	if err != nil {
+		return nil, err
+	}
+	tree.quotes, tree.bareBooleans = t.quotes, t.bareBooleans
+	return tree, nil
 }
 
 // Text constructs a TextNode
@@ -116,6 +254,18 @@ func Div(id string, class []string, children ...*exphtml.Node) *exphtml.Node {
 	return Element("div", attr, children...)
 }
 
+// IsVoidElement returns true for the elements, like <br> and <img>, that
+// can't have children and are rendered without an end tag.
+func IsVoidElement(tag string) bool {
+	return voidElements[strings.ToLower(tag)]
+}
+
+// IsBooleanAttrib returns true for the attributes, like checked and
+// disabled, whose presence alone has meaning.
+func IsBooleanAttrib(key string) bool {
+	return booleanAttribs[strings.ToLower(key)]
+}
+
 func Element(name string, attrs []exphtml.Attribute, children ...*exphtml.Node) *exphtml.Node {
 	n := &exphtml.Node{
 		Data: name,
@@ -0,0 +1,120 @@
+// Copyright 2011 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package h5
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ParseOptions control how NewWithOptions parses html.
+type ParseOptions struct {
+	// PreserveCDATA keeps <![CDATA[...]]> sections inside foreign content,
+	// inline <svg> and <math>, intact so they survive rendering. Normally
+	// they are turned into text. The sections are stored as html.RawNode
+	// nodes so they don't contribute to TextContent.
+	// CDATA isn't meaningful in html content where it is parsed as a bogus
+	// comment either way.
+	PreserveCDATA bool
+	// PreserveAttribQuotes remembers whether each attribute in the source
+	// was single quoted, double quoted, unquoted or had no value at all and
+	// writes it the same way when the Tree is rendered. Attributes that are
+	// added or have their values changed are double quoted.
+	PreserveAttribQuotes bool
+}
+
+// NewWithOptions constructs a new h5 parser from a io.Reader using opts.
+func NewWithOptions(r io.Reader, opts ParseOptions) (*Tree, error) {
+	var quotes quoteStyles
+	if opts.PreserveAttribQuotes {
+		src, styles, err := recordQuotes(r)
+		if err != nil {
+			return nil, err
+		}
+		r, quotes = src, styles
+	}
+	if opts.PreserveCDATA {
+		src, err := markCDATA(r)
+		if err != nil {
+			return nil, err
+		}
+		r = src
+	}
+	t, err := New(r)
+	if err != nil {
+		return nil, err
+	}
+	if opts.PreserveCDATA {
+		t.Walk(restoreCDATA)
+	}
+	t.quotes = quotes
+	return t, nil
+}
+
+const (
+	cdataStart = "<![CDATA["
+	cdataEnd   = "]]>"
+	// CDATA sections are smuggled through the parser as comments with
+	// these delimiters.
+	cdataCommentStart = "[CDATA["
+	cdataCommentEnd   = "]]"
+)
+
+// markCDATA rewrites the CDATA sections in foreign content as comments so
+// the parser keeps them as separate nodes.
+func markCDATA(r io.Reader) (io.Reader, error) {
+	var buf bytes.Buffer
+	z := html.NewTokenizer(r)
+	foreign := 0
+	for {
+		z.AllowCDATA(foreign > 0)
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return &buf, nil
+			}
+			return nil, z.Err()
+		case html.StartTagToken, html.EndTagToken:
+			name, _ := z.TagName()
+			if s := string(name); s == "svg" || s == "math" {
+				if tt == html.StartTagToken {
+					foreign++
+				} else if foreign > 0 {
+					foreign--
+				}
+			}
+		case html.TextToken:
+			raw := z.Raw()
+			if foreign > 0 && bytes.HasPrefix(raw, []byte(cdataStart)) &&
+				bytes.HasSuffix(raw, []byte(cdataEnd)) &&
+				!bytes.Contains(raw, []byte("-->")) {
+				buf.WriteString("<!--" + cdataCommentStart)
+				buf.Write(raw[len(cdataStart) : len(raw)-len(cdataEnd)])
+				buf.WriteString(cdataCommentEnd + "-->")
+				continue
+			}
+		}
+		buf.Write(z.Raw())
+	}
+}
+
+// restoreCDATA turns the comments marked by markCDATA back into CDATA
+// sections.
+func restoreCDATA(n *html.Node) {
+	if n.Type != html.CommentNode || n.Parent == nil || n.Parent.Namespace == "" {
+		return
+	}
+	if strings.HasPrefix(n.Data, cdataCommentStart) &&
+		strings.HasSuffix(n.Data, cdataCommentEnd) {
+		n.Type = html.RawNode
+		n.Data = cdataStart +
+			n.Data[len(cdataCommentStart):len(n.Data)-len(cdataCommentEnd)] +
+			cdataEnd
+	}
+}
@@ -0,0 +1,60 @@
+package h5
+
+import (
+	"strings"
+	"testing"
+
+	"code.google.com/p/go.net/html"
+)
+
+func TestPreserveCDATA(t *testing.T) {
+	src := "<html><head></head><body>" +
+		"<svg><script><![CDATA[if (a < b && c) {}]]></script></svg>" +
+		"<p><![CDATA[not foreign]]></p>" +
+		"</body></html>"
+	tree, err := NewWithOptions(strings.NewReader(src), ParseOptions{PreserveCDATA: true})
+	assertOrDie(t, err == nil, "error while parsing string: %s", err)
+	assertEqual(t, tree.String(), "<html><head></head><body>"+
+		"<svg><script><![CDATA[if (a < b && c) {}]]></script></svg>"+
+		"<p><!--[CDATA[not foreign]]--></p>"+
+		"</body></html>")
+	tree, err = NewFromString(src)
+	assertOrDie(t, err == nil, "error while parsing string: %s", err)
+	assertEqual(t, tree.String(), "<html><head></head><body>"+
+		"<svg><script>if (a &lt; b &amp;&amp; c) {}</script></svg>"+
+		"<p><!--[CDATA[not foreign]]--></p>"+
+		"</body></html>")
+}
+
+func TestPreserveAttribQuotes(t *testing.T) {
+	src := "<html><head></head><body>" +
+		"<p class='a b' id=foo title=\"it's\">x</p>" +
+		"<input type=checkbox checked data-x='\"q\"'>" +
+		"<img alt='' src=a.png>" +
+		"</body></html>"
+	tree, err := NewWithOptions(strings.NewReader(src),
+		ParseOptions{PreserveAttribQuotes: true})
+	assertOrDie(t, err == nil, "error while parsing string: %s", err)
+	assertEqual(t, tree.String(), "<html><head></head><body>"+
+		"<p class='a b' id=foo title=\"it&#39;s\">x</p>"+
+		"<input type=checkbox checked data-x='&#34;q&#34;'/>"+
+		"<img alt='' src=a.png />"+
+		"</body></html>")
+	clone := tree.Clone()
+	var p *html.Node
+	clone.Walk(func(n *html.Node) {
+		if n.Data == "p" {
+			p = n
+		}
+	})
+	p.Attr[0].Val = "c"
+	p.Attr = append(p.Attr, html.Attribute{Key: "lang", Val: "en"})
+	assertEqual(t, clone.String(), "<html><head></head><body>"+
+		"<p class=\"c\" id=foo title=\"it&#39;s\" lang=\"en\">x</p>"+
+		"<input type=checkbox checked data-x='&#34;q&#34;'/>"+
+		"<img alt='' src=a.png />"+
+		"</body></html>")
+	reparsed, err := tree.Reparse()
+	assertOrDie(t, err == nil, "error while reparsing: %s", err)
+	assertEqual(t, reparsed.String(), tree.String())
+}
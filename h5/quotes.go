@@ -0,0 +1,208 @@
+// Copyright 2011 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package h5
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// quoteStyle is how an attribute value was written in the source.
+type quoteStyle int
+
+const (
+	doubleQuoted quoteStyle = iota
+	singleQuoted
+	unquoted
+	// noValue is an attribute written without a value like <input disabled>.
+	noValue
+)
+
+// quoteKey identifies an attribute by its element and value so that it
+// still matches after the tree is transformed. An attribute whose value is
+// changed no longer matches and falls back to double quotes.
+type quoteKey struct {
+	tag, key, val string
+}
+
+type quoteStyles map[quoteKey]quoteStyle
+
+// isSpace returns true for the html whitespace characters.
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\f' || c == '\r'
+}
+
+// tagName returns the offset of the end of the tag name in a raw start tag.
+func tagName(raw []byte) int {
+	i := 1
+	for i < len(raw) && !isSpace(raw[i]) && raw[i] != '/' && raw[i] != '>' {
+		i++
+	}
+	return i
+}
+
+// scanAttribs calls f with the raw key and value of each attribute of a raw
+// start tag along with how the value was quoted.
+func scanAttribs(raw []byte, f func(key, val []byte, q quoteStyle)) {
+	i := tagName(raw)
+	for {
+		for i < len(raw) && (isSpace(raw[i]) || raw[i] == '/') {
+			i++
+		}
+		if i >= len(raw) || raw[i] == '>' {
+			return
+		}
+		start := i
+		for i < len(raw) && !isSpace(raw[i]) && raw[i] != '/' && raw[i] != '>' &&
+			(raw[i] != '=' || i == start) {
+			i++
+		}
+		key := raw[start:i]
+		for i < len(raw) && isSpace(raw[i]) {
+			i++
+		}
+		if i >= len(raw) || raw[i] != '=' {
+			f(key, nil, noValue)
+			continue
+		}
+		i++
+		for i < len(raw) && isSpace(raw[i]) {
+			i++
+		}
+		if i < len(raw) && (raw[i] == '"' || raw[i] == '\'') {
+			q := raw[i]
+			i++
+			start = i
+			for i < len(raw) && raw[i] != q {
+				i++
+			}
+			style := doubleQuoted
+			if q == '\'' {
+				style = singleQuoted
+			}
+			f(key, raw[start:i], style)
+			i++
+			continue
+		}
+		start = i
+		for i < len(raw) && !isSpace(raw[i]) && raw[i] != '>' {
+			i++
+		}
+		f(key, raw[start:i], unquoted)
+	}
+}
+
+// recordQuotes returns the quote style of every attribute in the source
+// along with a reader for the source. Attributes that appear with different
+// styles for the same element and value are left double quoted.
+func recordQuotes(r io.Reader) (io.Reader, quoteStyles, error) {
+	var buf bytes.Buffer
+	styles := make(quoteStyles)
+	conflicts := make(map[quoteKey]bool)
+	z := html.NewTokenizer(io.TeeReader(r, &buf))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if z.Err() != io.EOF {
+				return nil, nil, z.Err()
+			}
+			for k := range conflicts {
+				delete(styles, k)
+			}
+			return &buf, styles, nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			raw := z.Raw()
+			tag := strings.ToLower(string(raw[1:tagName(raw)]))
+			scanAttribs(raw, func(key, val []byte, q quoteStyle) {
+				k := quoteKey{tag, strings.ToLower(string(key)),
+					html.UnescapeString(string(val))}
+				if s, ok := styles[k]; ok && s != q {
+					conflicts[k] = true
+				}
+				styles[k] = q
+			})
+		}
+	}
+}
+
+// renderQuoted renders the nodes writing attributes with the quote style
+// they were parsed with. If bare is true empty boolean attributes are
+// written without a value.
+func renderQuoted(w io.Writer, ns []*html.Node, styles quoteStyles, bare bool) error {
+	var buf bytes.Buffer
+	if err := RenderNodes(&buf, ns); err != nil {
+		return err
+	}
+	z := html.NewTokenizer(&buf)
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if z.Err() == io.EOF {
+				return nil
+			}
+			return z.Err()
+		}
+		raw := z.Raw()
+		if tt == html.StartTagToken || tt == html.SelfClosingTagToken {
+			raw = requoteTag(raw, styles, bare)
+		}
+		if _, err := w.Write(raw); err != nil {
+			return err
+		}
+	}
+}
+
+// requoteTag rewrites a start tag as rendered by html.Render using the
+// recorded quote styles and writing empty boolean attributes bare if bare is
+// true.
+func requoteTag(raw []byte, styles quoteStyles, bare bool) []byte {
+	end := tagName(raw)
+	tag := strings.ToLower(string(raw[1:end]))
+	out := append([]byte{}, raw[:end]...)
+	last := doubleQuoted
+	scanAttribs(raw, func(key, val []byte, q quoteStyle) {
+		v := html.UnescapeString(string(val))
+		last = styles[quoteKey{tag, strings.ToLower(string(key)), v}]
+		switch {
+		case bare && v == "" && IsBooleanAttrib(string(key)):
+			last = noValue
+		case last == noValue && v != "":
+			last = doubleQuoted
+		case last == unquoted && !unquotable(val):
+			last = doubleQuoted
+		}
+		out = append(out, ' ')
+		out = append(out, key...)
+		switch last {
+		case singleQuoted:
+			out = append(out, "='"...)
+			out = append(out, val...)
+			out = append(out, '\'')
+		case unquoted:
+			out = append(out, '=')
+			out = append(out, val...)
+		case doubleQuoted:
+			out = append(out, "=\""...)
+			out = append(out, val...)
+			out = append(out, '"')
+		}
+	})
+	if bytes.HasSuffix(raw, []byte("/>")) {
+		if last == unquoted {
+			out = append(out, ' ')
+		}
+		return append(out, "/>"...)
+	}
+	return append(out, '>')
+}
+
+// unquotable returns true if an escaped attribute value can be written
+// without quotes.
+func unquotable(val []byte) bool {
+	return len(val) > 0 && bytes.IndexAny(val, " \t\n\f\r\"'=<>`") < 0
+}
@@ -0,0 +1,145 @@
+// Copyright 2011 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package h5
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// RenderOptions control the layout of html rendered by RenderIndented.
+type RenderOptions struct {
+	// Indent is written once for each level of nesting. Defaults to two
+	// spaces.
+	Indent string
+	// WrapAttribs puts each attribute of a start tag on its own line if the
+	// tag would make the line longer than MaxWidth.
+	WrapAttribs bool
+	// MaxWidth is the line width after which attributes are wrapped.
+	// Zero means lines are never wrapped.
+	MaxWidth int
+}
+
+// RenderIndented renders the nodes with one element, text or comment per
+// line indented according to its depth in the tree. Whitespace only text is
+// dropped and other text is trimmed, except inside whitespace sensitive
+// elements such as <pre>, <textarea>, <script> and <style> which are
+// rendered verbatim.
+func RenderIndented(w io.Writer, ns []*html.Node, opts RenderOptions) error {
+	if opts.Indent == "" {
+		opts.Indent = "  "
+	}
+	bw := bufio.NewWriter(w)
+	r := &indentRenderer{w: bw, opts: opts}
+	for _, n := range ns {
+		if err := r.render(n, 0); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+type indentRenderer struct {
+	w    *bufio.Writer
+	opts RenderOptions
+}
+
+func (r *indentRenderer) indent(depth int) string {
+	return strings.Repeat(r.opts.Indent, depth)
+}
+
+func (r *indentRenderer) line(depth int, s string) error {
+	_, err := r.w.WriteString(r.indent(depth) + s + "\n")
+	return err
+}
+
+func (r *indentRenderer) render(n *html.Node, depth int) error {
+	switch n.Type {
+	case html.DocumentNode:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if err := r.render(c, depth); err != nil {
+				return err
+			}
+		}
+		return nil
+	case html.TextNode:
+		if s := strings.TrimSpace(n.Data); s != "" {
+			return r.line(depth, html.EscapeString(s))
+		}
+		return nil
+	case html.CommentNode:
+		return r.line(depth, "<!--"+n.Data+"-->")
+	case html.ElementNode:
+		return r.renderElement(n, depth)
+	}
+	// Doctypes and raw nodes are rendered as is.
+	if _, err := r.w.WriteString(r.indent(depth)); err != nil {
+		return err
+	}
+	if err := html.Render(r.w, n); err != nil {
+		return err
+	}
+	return r.w.WriteByte('\n')
+}
+
+func (r *indentRenderer) renderElement(n *html.Node, depth int) error {
+	if verbatimElements[n.Data] && n.Namespace == "" {
+		if _, err := r.w.WriteString(r.indent(depth)); err != nil {
+			return err
+		}
+		if err := html.Render(r.w, n); err != nil {
+			return err
+		}
+		return r.w.WriteByte('\n')
+	}
+	start := r.startTag(n, depth)
+	if IsVoidElement(n.Data) {
+		return r.line(depth, start+"/>")
+	}
+	end := "</" + n.Data + ">"
+	c := n.FirstChild
+	switch {
+	case c == nil:
+		return r.line(depth, start+">"+end)
+	case c.NextSibling == nil && c.Type == html.TextNode:
+		return r.line(depth,
+			start+">"+html.EscapeString(strings.TrimSpace(c.Data))+end)
+	}
+	if err := r.line(depth, start+">"); err != nil {
+		return err
+	}
+	for ; c != nil; c = c.NextSibling {
+		if err := r.render(c, depth+1); err != nil {
+			return err
+		}
+	}
+	return r.line(depth, end)
+}
+
+// startTag returns the start tag of n without the closing '>', wrapping the
+// attributes onto their own lines if they don't fit.
+func (r *indentRenderer) startTag(n *html.Node, depth int) string {
+	attrs := make([]string, len(n.Attr))
+	width := len(r.indent(depth)) + len(n.Data) + 2
+	for i, a := range n.Attr {
+		key := a.Key
+		if a.Namespace != "" {
+			key = a.Namespace + ":" + key
+		}
+		attrs[i] = key + "=\"" + html.EscapeString(a.Val) + "\""
+		width += len(attrs[i]) + 1
+	}
+	if len(attrs) == 0 {
+		return "<" + n.Data
+	}
+	if r.opts.WrapAttribs && r.opts.MaxWidth > 0 && width > r.opts.MaxWidth {
+		sep := "\n" + r.indent(depth+1)
+		return "<" + n.Data + sep + strings.Join(attrs, sep)
+	}
+	return "<" + n.Data + " " + strings.Join(attrs, " ")
+}
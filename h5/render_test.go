@@ -0,0 +1,67 @@
+package h5
+
+import (
+	"bytes"
+	"testing"
+
+	"code.google.com/p/go.net/html"
+)
+
+func renderIndented(t *testing.T, s string, opts RenderOptions) string {
+	ns, err := PartialFromString(s)
+	assertOrDie(t, err == nil, "error while parsing string: %s", err)
+	buf := bytes.NewBufferString("")
+	err = RenderIndented(buf, ns, opts)
+	assertOrDie(t, err == nil, "error while rendering: %s", err)
+	return buf.String()
+}
+
+func TestRenderIndented(t *testing.T) {
+	assertEqual(t, renderIndented(t,
+		"<div id=\"a\"><p>foo <b>bar</b></p>\n  <br><pre> x\n  y</pre><!--c--><span></span></div>",
+		RenderOptions{}),
+		"<div id=\"a\">\n"+
+			"  <p>\n"+
+			"    foo\n"+
+			"    <b>bar</b>\n"+
+			"  </p>\n"+
+			"  <br/>\n"+
+			"  <pre> x\n  y</pre>\n"+
+			"  <!--c-->\n"+
+			"  <span></span>\n"+
+			"</div>\n")
+}
+
+func TestRenderIndentedWrapsAttribs(t *testing.T) {
+	s := "<div><input type=\"text\" name=\"username\" placeholder=\"Your name\"><a href=\"/\">home</a></div>"
+	assertEqual(t, renderIndented(t, s,
+		RenderOptions{Indent: "\t", WrapAttribs: true, MaxWidth: 40}),
+		"<div>\n"+
+			"\t<input\n"+
+			"\t\ttype=\"text\"\n"+
+			"\t\tname=\"username\"\n"+
+			"\t\tplaceholder=\"Your name\"/>\n"+
+			"\t<a href=\"/\">home</a>\n"+
+			"</div>\n")
+	assertEqual(t, renderIndented(t, s, RenderOptions{Indent: "\t", MaxWidth: 40}),
+		"<div>\n"+
+			"\t<input type=\"text\" name=\"username\" placeholder=\"Your name\"/>\n"+
+			"\t<a href=\"/\">home</a>\n"+
+			"</div>\n")
+}
+
+func TestRenderIndentedDocument(t *testing.T) {
+	tree, err := NewFromString("<!DOCTYPE html><html><head><title>t</title></head><body><script>if (a < b) {}</script></body></html>")
+	assertOrDie(t, err == nil, "error while parsing string: %s", err)
+	buf := bytes.NewBufferString("")
+	RenderIndented(buf, []*html.Node{tree.Top()}, RenderOptions{})
+	assertEqual(t, buf.String(), "<!DOCTYPE html>\n"+
+		"<html>\n"+
+		"  <head>\n"+
+		"    <title>t</title>\n"+
+		"  </head>\n"+
+		"  <body>\n"+
+		"    <script>if (a < b) {}</script>\n"+
+		"  </body>\n"+
+		"</html>\n")
+}
@@ -63,4 +63,66 @@ var (
 		"ol":             true,
 		"ul":             true,
 	}
+	// Elements that can't have any content and have no end tag.
+	voidElements = map[string]bool{
+		"area":   true,
+		"base":   true,
+		"br":     true,
+		"col":    true,
+		"embed":  true,
+		"hr":     true,
+		"img":    true,
+		"input":  true,
+		"keygen": true,
+		"link":   true,
+		"meta":   true,
+		"param":  true,
+		"source": true,
+		"track":  true,
+		"wbr":    true,
+	}
+	// Elements whose content is whitespace sensitive or not html so it
+	// must be rendered exactly as is.
+	verbatimElements = map[string]bool{
+		"iframe":    true,
+		"listing":   true,
+		"noembed":   true,
+		"noframes":  true,
+		"noscript":  true,
+		"plaintext": true,
+		"pre":       true,
+		"script":    true,
+		"style":     true,
+		"textarea":  true,
+		"xmp":       true,
+	}
+	// Attributes whose presence alone has meaning so an empty value is
+	// legitimate.
+	booleanAttribs = map[string]bool{
+		"allowfullscreen": true,
+		"async":           true,
+		"autofocus":       true,
+		"autoplay":        true,
+		"checked":         true,
+		"controls":        true,
+		"default":         true,
+		"defer":           true,
+		"disabled":        true,
+		"formnovalidate":  true,
+		"hidden":          true,
+		"inert":           true,
+		"ismap":           true,
+		"itemscope":       true,
+		"loop":            true,
+		"multiple":        true,
+		"muted":           true,
+		"nomodule":        true,
+		"novalidate":      true,
+		"open":            true,
+		"playsinline":     true,
+		"readonly":        true,
+		"required":        true,
+		"reversed":        true,
+		"selected":        true,
+	}
 )
@@ -0,0 +1,163 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"fmt"
+	"strconv"
+
+	"golang.org/x/net/html"
+
+	"code.google.com/p/go-html-transform/h5"
+)
+
+// isFocusable returns true for elements that take keyboard focus by default.
+func isFocusable(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	if _, disabled := getAttr(n, "disabled"); disabled {
+		return false
+	}
+	switch h5.Data(n) {
+	case "a":
+		_, ok := getAttr(n, "href")
+		return ok
+	case "input":
+		typ, _ := getAttr(n, "type")
+		return typ != "hidden"
+	case "button", "select", "textarea":
+		return true
+	}
+	return false
+}
+
+// HiddenFocusable finds the elements in the tree rooted at n that have
+// aria-hidden="true" but contain focusable elements. Keyboard users can
+// reach those elements even though assistive technology hides them.
+// It can be used as a Collector with CollectorFunc.
+func HiddenFocusable(n *html.Node) []*html.Node {
+	var found []*html.Node
+	h5.WalkNodes(n, func(n *html.Node) {
+		if n.Type != html.ElementNode {
+			return
+		}
+		if hidden, _ := getAttr(n, "aria-hidden"); hidden != "true" {
+			return
+		}
+		focusable := false
+		h5.WalkNodes(n, func(c *html.Node) {
+			focusable = focusable || isFocusable(c)
+		})
+		if focusable {
+			found = append(found, n)
+		}
+	})
+	return found
+}
+
+// UnhideFocusable removes aria-hidden from the elements HiddenFocusable
+// finds in the document and returns them.
+func (t *Transformer) UnhideFocusable() []*html.Node {
+	var fixed []*html.Node
+	t.ApplyWithCollector(func(n *html.Node) {
+		RemoveAttrib("aria-hidden")(n)
+		fixed = append(fixed, n)
+	}, CollectorFunc(HiddenFocusable))
+	return fixed
+}
+
+// AssignTabindex sets tabindex on the focusable elements matched by the CSS3
+// selector in document order, counting up from start. Matched elements that
+// can't take focus, like disabled inputs, are skipped. If keepExisting is
+// true elements that already have a tabindex are skipped too and don't use
+// up a number.
+func (t *Transformer) AssignTabindex(sel string, start int, keepExisting bool) error {
+	next := start
+	return t.Apply(func(n *html.Node) {
+		if !isFocusable(n) {
+			return
+		}
+		if _, ok := getAttr(n, "tabindex"); ok && keepExisting {
+			return
+		}
+		ModifyAttrib("tabindex", strconv.Itoa(next))(n)
+		next++
+	}, sel)
+}
+
+// isDecorative returns true for elements marked as presentational or
+// hidden from assistive technology.
+func isDecorative(n *html.Node) bool {
+	role, _ := getAttr(n, "role")
+	hidden, _ := getAttr(n, "aria-hidden")
+	return role == "presentation" || role == "none" || hidden == "true"
+}
+
+// EnsureAltText adds an alt attribute to every img in the document that
+// doesn't have one and returns the images it changed for auditing.
+// Decorative images, those with role="presentation" or role="none" or
+// aria-hidden="true", get an empty alt so screen readers skip them. The
+// rest get defaultAlt. Existing alt attributes, even empty ones, are kept.
+func (t *Transformer) EnsureAltText(defaultAlt string) []*html.Node {
+	var fixed []*html.Node
+	t.Apply(func(n *html.Node) {
+		alt := defaultAlt
+		if isDecorative(n) {
+			alt = ""
+		}
+		ModifyAttrib("alt", alt)(n)
+		fixed = append(fixed, n)
+	}, "img:not([alt])")
+	return fixed
+}
+
+// AddSkipLink inserts a link to #targetID with the given label, or
+// "Skip to content" if label is empty, as the first child of the body so
+// keyboard users can jump past the navigation. If mainSel isn't empty the
+// first element it matches becomes the target and is given targetID as its
+// id if it has none. A target with an id already keeps it and the link
+// points to that id instead. It returns an error if mainSel doesn't match
+// or the document has no body. A body that already starts with a link to
+// the target is left alone.
+//
+//	t.AddSkipLink("main", "", "main")
+func (t *Transformer) AddSkipLink(targetID, label, mainSel string) error {
+	body := t.Body()
+	if body == nil {
+		return fmt.Errorf("No body to add a skip link to")
+	}
+	if mainSel != "" {
+		target, err := first(t.root(), mainSel)
+		if err != nil {
+			return err
+		}
+		if target == nil {
+			return fmt.Errorf("No element matched %q", mainSel)
+		}
+		if id, ok := getAttr(target, "id"); ok && id != "" {
+			targetID = id
+		} else {
+			ModifyAttrib("id", targetID)(target)
+		}
+	}
+	if label == "" {
+		label = "Skip to content"
+	}
+	href := "#" + targetID
+	c := body.FirstChild
+	for c != nil && isWhitespace(c) {
+		c = c.NextSibling
+	}
+	if c != nil && isElement(c, "a") {
+		if v, _ := getAttr(c, "href"); v == href {
+			return nil
+		}
+	}
+	link := h5.Anchor(href, label)
+	AddClass("skip-link")(link)
+	PrependChildren(link)(body)
+	return nil
+}
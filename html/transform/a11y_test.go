@@ -0,0 +1,116 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"testing"
+
+	"code.google.com/p/go-html-transform/h5"
+)
+
+func TestHiddenFocusable(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<div id=\"a\" aria-hidden=\"true\"><p><button>ok</button></p></div>" +
+		"<div id=\"b\" aria-hidden=\"true\"><a>no href</a><input type=\"hidden\"></div>" +
+		"<div id=\"c\" aria-hidden=\"false\"><button>ok</button></div>" +
+		"<span id=\"d\" aria-hidden=\"true\"><input disabled><a href=\"/\">x</a></span>" +
+		"</body></html>")
+	tf := New(tree)
+	found := HiddenFocusable(tf.Doc())
+	assertEqual(t, len(found), 2)
+	assertEqual(t, h5.NewTree(found[0]).String(),
+		"<div id=\"a\" aria-hidden=\"true\"><p><button>ok</button></p></div>")
+	fixed := tf.UnhideFocusable()
+	assertEqual(t, len(fixed), 2)
+	assertEqual(t, len(HiddenFocusable(tf.Doc())), 0)
+	assertEqual(t, h5.NewTree(fixed[1]).String(),
+		"<span id=\"d\"><input disabled=\"\"/><a href=\"/\">x</a></span>")
+}
+
+func TestAssignTabindex(t *testing.T) {
+	src := "<html><body><form>" +
+		"<input id=\"a\"><input id=\"b\" disabled>" +
+		"<input id=\"c\" tabindex=\"9\"><input id=\"d\">" +
+		"</form></body></html>"
+	tree, _ := h5.NewFromString(src)
+	tf := New(tree)
+	if err := tf.AssignTabindex("form input", 1, false); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	assertEqual(t, tf.String(), "<html><head></head><body><form>"+
+		"<input id=\"a\" tabindex=\"1\"/><input id=\"b\" disabled=\"\"/>"+
+		"<input id=\"c\" tabindex=\"2\"/><input id=\"d\" tabindex=\"3\"/>"+
+		"</form></body></html>")
+
+	tree, _ = h5.NewFromString(src)
+	tf = New(tree)
+	if err := tf.AssignTabindex("input", 1, true); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	assertEqual(t, tf.String(), "<html><head></head><body><form>"+
+		"<input id=\"a\" tabindex=\"1\"/><input id=\"b\" disabled=\"\"/>"+
+		"<input id=\"c\" tabindex=\"9\"/><input id=\"d\" tabindex=\"2\"/>"+
+		"</form></body></html>")
+}
+
+func TestEnsureAltText(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<img src=\"a.png\">" +
+		"<img src=\"b.png\" alt=\"Bee\">" +
+		"<img src=\"c.png\" alt=\"\">" +
+		"<img src=\"d.png\" role=\"presentation\">" +
+		"<img src=\"e.png\" aria-hidden=\"true\">" +
+		"</body></html>")
+	tf := New(tree)
+	fixed := tf.EnsureAltText("image")
+	assertEqual(t, h5.RenderNodesToString(fixed),
+		"<img src=\"a.png\" alt=\"image\"/>"+
+			"<img src=\"d.png\" role=\"presentation\" alt=\"\"/>"+
+			"<img src=\"e.png\" aria-hidden=\"true\" alt=\"\"/>")
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<img src=\"a.png\" alt=\"image\"/>"+
+		"<img src=\"b.png\" alt=\"Bee\"/>"+
+		"<img src=\"c.png\" alt=\"\"/>"+
+		"<img src=\"d.png\" role=\"presentation\" alt=\"\"/>"+
+		"<img src=\"e.png\" aria-hidden=\"true\" alt=\"\"/>"+
+		"</body></html>")
+	if fixed := tf.EnsureAltText("image"); len(fixed) != 0 {
+		t.Errorf("Expected nothing to fix the second time got %d", len(fixed))
+	}
+}
+
+func TestAddSkipLink(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<nav>menu</nav><div class=\"content\">text</div>" +
+		"</body></html>")
+	tf := New(tree)
+	expected := "<html><head></head><body>" +
+		"<a href=\"#main\" class=\"skip-link\">Skip to content</a>" +
+		"<nav>menu</nav><div class=\"content\" id=\"main\">text</div>" +
+		"</body></html>"
+	if err := tf.AddSkipLink("main", "", ".content"); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, tf.String(), expected)
+	if err := tf.AddSkipLink("main", "", ".content"); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, tf.String(), expected)
+
+	tree, _ = h5.NewFromString("<html><body>" +
+		"<nav>menu</nav><main id=\"top\">text</main>" +
+		"</body></html>")
+	tf = New(tree)
+	if err := tf.AddSkipLink("content", "Skip navigation", "main"); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<a href=\"#top\" class=\"skip-link\">Skip navigation</a>"+
+		"<nav>menu</nav><main id=\"top\">text</main>"+
+		"</body></html>")
+	if err := tf.AddSkipLink("content", "", "article"); err == nil {
+		t.Errorf("Expected an error for a main selector that doesn't match")
+	}
+}
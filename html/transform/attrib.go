@@ -0,0 +1,132 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"code.google.com/p/go-html-transform/h5"
+)
+
+// getAttr returns the value of the attribute with the given key and
+// whether it was present.
+func getAttr(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+// removeAttr removes every attribute with the given key from n.
+func removeAttr(n *html.Node, key string) {
+	removeAttrIf(n, func(a html.Attribute) bool { return a.Key == key })
+}
+
+// removeAttrIf removes the attributes of n that f returns true for.
+func removeAttrIf(n *html.Node, f func(html.Attribute) bool) {
+	kept := n.Attr[:0]
+	for _, a := range n.Attr {
+		if !f(a) {
+			kept = append(kept, a)
+		}
+	}
+	n.Attr = kept
+}
+
+func attrContains(val, list string) bool {
+	for _, v := range strings.Fields(list) {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
+
+// meaningfulEmpty are the attributes whose empty value means something. An
+// empty alt marks an image as decorative and an empty option value stops it
+// falling back to the option's label.
+var meaningfulEmpty = map[string]bool{
+	"alt":   true,
+	"value": true,
+}
+
+// RemoveEmptyAttribs creates a TransformFunc that removes the attributes with
+// an empty value from the node it operates on. If keys are given only those
+// attributes are considered. Boolean attributes such as disabled are always
+// kept since an empty value is how they are written. alt and value are kept
+// too, since their empty values have a meaning, unless they are named in
+// keys.
+func RemoveEmptyAttribs(keys ...string) TransformFunc {
+	return func(n *html.Node) {
+		removeAttrIf(n, func(a html.Attribute) bool {
+			if a.Val != "" || h5.IsBooleanAttrib(a.Key) {
+				return false
+			}
+			for _, k := range keys {
+				if a.Key == k {
+					return true
+				}
+			}
+			return len(keys) == 0 && !meaningfulEmpty[strings.ToLower(a.Key)]
+		})
+	}
+}
+
+// NormalizeAttribWhitespace creates a TransformFunc that trims the values of
+// the attributes with the given keys and collapses the whitespace inside them
+// to single spaces. It is meant for space separated token lists like class
+// and rel. Other attributes are left alone since whitespace can be
+// significant in them.
+//
+//	class="  a   b  " => class="a b"
+func NormalizeAttribWhitespace(keys ...string) TransformFunc {
+	return func(n *html.Node) {
+		for i, a := range n.Attr {
+			for _, k := range keys {
+				if a.Key == k {
+					n.Attr[i].Val = strings.Join(strings.Fields(a.Val), " ")
+					break
+				}
+			}
+		}
+	}
+}
+
+// canonicalBoolean returns true if the value of the boolean attribute a
+// carries no meaning. hidden is the exception as hidden="until-found" is a
+// different state than plain hidden.
+func canonicalBoolean(a html.Attribute) bool {
+	if a.Namespace != "" || !h5.IsBooleanAttrib(a.Key) {
+		return false
+	}
+	if strings.ToLower(a.Key) == "hidden" {
+		v := strings.ToLower(strings.TrimSpace(a.Val))
+		return v == "" || v == "hidden"
+	}
+	return true
+}
+
+// CanonicalizeBooleans rewrites every boolean attribute in the document,
+// whether written as checked="checked", checked="true" or bare, to the bare
+// form. The value of a boolean attribute is ignored by browsers, even
+// checked="false" means checked, so only its presence is kept. Values of
+// hidden other than "hidden", like hidden="until-found", have a meaning and
+// are left alone.
+//
+//	<input checked="checked"> => <input checked>
+func (t *Transformer) CanonicalizeBooleans() {
+	h5.WalkNodes(t.Doc(), func(n *html.Node) {
+		for i, a := range n.Attr {
+			if n.Type == html.ElementNode && canonicalBoolean(a) {
+				n.Attr[i].Val = ""
+			}
+		}
+	})
+	t.doc.SetBareBooleans(true)
+}
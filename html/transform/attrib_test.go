@@ -0,0 +1,75 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"testing"
+
+	"golang.org/x/net/html"
+
+	"code.google.com/p/go-html-transform/h5"
+)
+
+func TestRemoveEmptyAttribs(t *testing.T) {
+	attrs := []html.Attribute{
+		{Key: "class", Val: ""},
+		{Key: "disabled", Val: ""},
+		{Key: "style", Val: ""},
+		{Key: "name", Val: "foo"},
+	}
+	node := h5.Element("input", append([]html.Attribute{}, attrs...))
+	RemoveEmptyAttribs()(node)
+	assertEqual(t, h5.NewTree(node).String(), "<input disabled=\"\" name=\"foo\"/>")
+	node = h5.Element("input", append([]html.Attribute{}, attrs...))
+	RemoveEmptyAttribs("class", "disabled")(node)
+	assertEqual(t, h5.NewTree(node).String(),
+		"<input disabled=\"\" style=\"\" name=\"foo\"/>")
+
+	img := h5.Element("img", []html.Attribute{{Key: "src", Val: "a.png"}, {Key: "alt", Val: ""}, {Key: "title", Val: ""}})
+	RemoveEmptyAttribs()(img)
+	assertEqual(t, h5.NewTree(img).String(), "<img src=\"a.png\" alt=\"\"/>")
+	RemoveEmptyAttribs("alt")(img)
+	assertEqual(t, h5.NewTree(img).String(), "<img src=\"a.png\"/>")
+	opt := h5.Element("option", []html.Attribute{{Key: "value", Val: ""}}, h5.Text("Pick one"))
+	RemoveEmptyAttribs()(opt)
+	assertEqual(t, h5.NewTree(opt).String(), "<option value=\"\">Pick one</option>")
+}
+
+func TestNormalizeAttribWhitespace(t *testing.T) {
+	node := h5.Element("a", []html.Attribute{
+		{Key: "class", Val: "  a \t  b\n "},
+		{Key: "rel", Val: "noopener   nofollow"},
+		{Key: "title", Val: "  keep   this "},
+	})
+	NormalizeAttribWhitespace("class", "rel")(node)
+	assertEqual(t, h5.NewTree(node).String(),
+		"<a class=\"a b\" rel=\"noopener nofollow\" title=\"  keep   this \"></a>")
+}
+
+func TestCanonicalizeBooleans(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body><form>" +
+		"<input type=\"checkbox\" checked=\"checked\">" +
+		"<input type=\"checkbox\" checked=\"true\">" +
+		"<input type=\"checkbox\" checked>" +
+		"<select multiple=\"\"><option value=\"\" selected=\"selected\">a</option></select>" +
+		"<p hidden=\"hidden\">a</p><p hidden=\"until-found\">b</p>" +
+		"</form></body></html>")
+	tf := New(tree)
+	tf.CanonicalizeBooleans()
+	assertEqual(t, tf.String(), "<html><head></head><body><form>"+
+		"<input type=\"checkbox\" checked/>"+
+		"<input type=\"checkbox\" checked/>"+
+		"<input type=\"checkbox\" checked/>"+
+		"<select multiple><option value=\"\" selected>a</option></select>"+
+		"<p hidden>a</p><p hidden=\"until-found\">b</p>"+
+		"</form></body></html>")
+	assertEqual(t, tree.String(), "<html><head></head><body><form>"+
+		"<input type=\"checkbox\" checked=\"checked\"/>"+
+		"<input type=\"checkbox\" checked=\"true\"/>"+
+		"<input type=\"checkbox\" checked=\"\"/>"+
+		"<select multiple=\"\"><option value=\"\" selected=\"selected\">a</option></select>"+
+		"<p hidden=\"hidden\">a</p><p hidden=\"until-found\">b</p>"+
+		"</form></body></html>")
+}
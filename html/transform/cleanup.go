@@ -0,0 +1,249 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"code.google.com/p/go-html-transform/css/selector"
+	"code.google.com/p/go-html-transform/h5"
+)
+
+func isWhitespace(n *html.Node) bool {
+	return n.Type == html.TextNode && strings.TrimSpace(n.Data) == ""
+}
+
+// isBlank returns true if n has no children other than whitespace.
+func isBlank(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if !isWhitespace(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// StripWhitespaceTextIn removes the text children of every element matched
+// by the CSS3 selector that are only whitespace. It is meant for containers
+// like <select> and <table> where stray whitespace can be rendered or end
+// up in the wrong place. Text with anything other than whitespace is kept.
+//
+//	<select> <option>a</option> </select> => <select><option>a</option></select>
+func (t *Transformer) StripWhitespaceTextIn(sel string) error {
+	return t.Apply(func(n *html.Node) {
+		for c := n.FirstChild; c != nil; {
+			next := c.NextSibling
+			if isWhitespace(c) {
+				n.RemoveChild(c)
+			}
+			c = next
+		}
+	}, sel)
+}
+
+// DedupeAdjacent removes every element matched by the CSS3 selector that is
+// structurally identical to the preceding sibling element when that sibling
+// is also matched. Whitespace between the two is ignored.
+//
+//	<hr><hr><p>foo</p><hr> => <hr><p>foo</p><hr>
+func (t *Transformer) DedupeAdjacent(sel string) error {
+	chn, err := selector.Selector(sel)
+	if err != nil {
+		return err
+	}
+	n := t.root()
+	if n == nil {
+		return nil
+	}
+	found := chn.Find(n)
+	matched := make(map[*html.Node]bool, len(found))
+	for _, n := range found {
+		matched[n] = true
+	}
+	for _, n := range found {
+		prev := n.PrevSibling
+		for prev != nil && isWhitespace(prev) {
+			prev = prev.PrevSibling
+		}
+		if prev != nil && matched[prev] && n.Parent != nil && h5.Equal(prev, n) {
+			n.Parent.RemoveChild(n)
+		}
+	}
+	return nil
+}
+
+// headKey returns the key that identifies duplicates of a singleton head
+// element or "" if n can appear more than once.
+func headKey(n *html.Node) string {
+	switch {
+	case isElement(n, "title"):
+		return "title"
+	case isElement(n, "meta"):
+		if _, ok := getAttr(n, "charset"); ok {
+			return "charset"
+		}
+		if name, _ := getAttr(n, "name"); strings.EqualFold(name, "viewport") {
+			return "viewport"
+		}
+	case isElement(n, "link"):
+		href, ok := getAttr(n, "href")
+		if !ok {
+			return ""
+		}
+		rel, _ := getAttr(n, "rel")
+		return "link " + strings.ToLower(strings.Join(strings.Fields(rel), " ")) +
+			" " + href
+	}
+	return ""
+}
+
+// DedupeHead removes duplicate elements from the document's <head> as
+// happens when pages are assembled from templates. Only the first of each
+// of these is kept:
+//
+//	<title>
+//	<meta charset>
+//	<meta name="viewport">
+//	<link> with the same rel and href
+//
+// Everything else is left alone even if it is repeated.
+func (t *Transformer) DedupeHead() {
+	head := t.Head()
+	if head == nil {
+		return
+	}
+	seen := make(map[string]bool)
+	for c := head.FirstChild; c != nil; {
+		next := c.NextSibling
+		if key := headKey(c); key != "" {
+			if seen[key] {
+				head.RemoveChild(c)
+			}
+			seen[key] = true
+		}
+		c = next
+	}
+}
+
+// semanticTags is the default mapping used by Semanticize.
+var semanticTags = map[string]string{
+	"b": "strong",
+	"i": "em",
+}
+
+// Semanticize creates a TransformFunc that renames the elements in the tree
+// rooted at the node it operates on according to mapping, which maps old
+// tags to new ones. A nil mapping renames <b> to <strong> and <i> to <em>.
+// Attributes and children are preserved and elements that aren't in the
+// mapping, including ones that were already renamed, are left alone.
+//
+//	<b><i>text</i></b> => <strong><em>text</em></strong>
+func Semanticize(mapping map[string]string) TransformFunc {
+	if mapping == nil {
+		mapping = semanticTags
+	}
+	return func(n *html.Node) {
+		h5.WalkNodes(n, func(n *html.Node) {
+			if n.Type != html.ElementNode {
+				return
+			}
+			if tag, ok := mapping[h5.Data(n)]; ok {
+				Rename(tag)(n)
+			}
+		})
+	}
+}
+
+// FixVoidChildren creates a TransformFunc that moves any children of the
+// void elements, like <br> and <img>, in the tree rooted at the node it
+// operates on out to follow the element as siblings. Void elements can't
+// have children and the renderer refuses to write them, so this repairs
+// trees where a transform put content inside one.
+//
+//	<br>content</br> => <br/>content
+func FixVoidChildren() TransformFunc {
+	return func(n *html.Node) {
+		var void []*html.Node
+		h5.WalkNodes(n, func(n *html.Node) {
+			if n.Type == html.ElementNode && n.FirstChild != nil &&
+				h5.IsVoidElement(h5.Data(n)) {
+				void = append(void, n)
+			}
+		})
+		// Deepest first so children moved out of a nested void element are
+		// then moved out of its ancestors too.
+		for i := len(void) - 1; i >= 0; i-- {
+			v := void[i]
+			for c := v.LastChild; c != nil; c = v.LastChild {
+				v.RemoveChild(c)
+				if v.Parent != nil {
+					v.Parent.InsertBefore(c, v.NextSibling)
+				}
+			}
+		}
+	}
+}
+
+// mergeAttribs copies the attributes of src onto dst combining their
+// classes. It returns false without changing dst if both have an attribute
+// other than class with different values.
+func mergeAttribs(dst, src *html.Node) bool {
+	for _, a := range src.Attr {
+		if v, ok := getAttr(dst, a.Key); ok && a.Key != "class" && v != a.Val {
+			return false
+		}
+	}
+	for _, a := range src.Attr {
+		if a.Key == "class" {
+			AddClass(strings.Fields(a.Val)...)(dst)
+		} else {
+			ModifyAttrib(a.Key, a.Val)(dst)
+		}
+	}
+	return true
+}
+
+// CollapseRedundantWrappers merges every element matched by the CSS3
+// selector with its child when that child is the only thing in it, apart
+// from whitespace, and has the same tag. The element takes over the child's
+// attributes and children, and their classes are combined. Elements whose
+// attributes disagree with their child's, like two different ids, are left
+// alone.
+//
+//	<div class="a"><div class="b">foo</div></div> => <div class="a b">foo</div>
+func (t *Transformer) CollapseRedundantWrappers(sel string) error {
+	chn, err := selector.Selector(sel)
+	if err != nil {
+		return err
+	}
+	n := t.root()
+	if n == nil {
+		return nil
+	}
+	for _, n := range chn.Find(n) {
+		if n.Parent == nil {
+			// Already merged into its parent.
+			continue
+		}
+		for {
+			c := n.FirstChild
+			for c != nil && isWhitespace(c) {
+				c = c.NextSibling
+			}
+			if c == nil || !isElement(c, h5.Data(n)) || !onlyElementChild(c) ||
+				!mergeAttribs(n, c) {
+				break
+			}
+			removeChildren(n)
+			for gc := c.FirstChild; gc != nil; gc = c.FirstChild {
+				c.RemoveChild(gc)
+				n.AppendChild(gc)
+			}
+		}
+	}
+	return nil
+}
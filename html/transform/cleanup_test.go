@@ -0,0 +1,113 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"testing"
+
+	"code.google.com/p/go-html-transform/h5"
+)
+
+func TestDedupeAdjacent(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<hr><hr>\n<hr class=\"x\"><p>foo</p><hr><ul><li>a</li><li>a</li><li>b</li></ul>" +
+		"</body></html>")
+	tf := New(tree)
+	tf.DedupeAdjacent("hr")
+	tf.DedupeAdjacent("li")
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<hr/>\n<hr class=\"x\"/><p>foo</p><hr/><ul><li>a</li><li>b</li></ul>"+
+		"</body></html>")
+}
+
+func TestDedupeHead(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><head>" +
+		"<meta charset=\"utf-8\"><title>first</title>" +
+		"<meta name=\"viewport\" content=\"width=device-width\">" +
+		"<link rel=\"stylesheet\" href=\"a.css\">" +
+		"<meta charset=\"latin1\"><title>second</title>" +
+		"<meta name=\"Viewport\" content=\"width=1024\">" +
+		"<link rel=\"Stylesheet\" href=\"a.css\">" +
+		"<link rel=\"preload\" href=\"a.css\">" +
+		"<meta name=\"keywords\" content=\"a\"><meta name=\"keywords\" content=\"a\">" +
+		"</head><body><title>body title</title></body></html>")
+	tf := New(tree)
+	tf.DedupeHead()
+	assertEqual(t, tf.String(), "<html><head>"+
+		"<meta charset=\"utf-8\"/><title>first</title>"+
+		"<meta name=\"viewport\" content=\"width=device-width\"/>"+
+		"<link rel=\"stylesheet\" href=\"a.css\"/>"+
+		"<link rel=\"preload\" href=\"a.css\"/>"+
+		"<meta name=\"keywords\" content=\"a\"/><meta name=\"keywords\" content=\"a\"/>"+
+		"</head><body><title>body title</title></body></html>")
+}
+
+func TestSemanticize(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body><p>" +
+		"<b class=\"x\"><i>text</i></b> <strong>already</strong> <u>u</u>" +
+		"</p></body></html>")
+	tf := New(tree)
+	tf.Apply(Semanticize(nil), "body")
+	expected := "<html><head></head><body><p>" +
+		"<strong class=\"x\"><em>text</em></strong> <strong>already</strong> <u>u</u>" +
+		"</p></body></html>"
+	assertEqual(t, tf.String(), expected)
+	tf.Apply(Semanticize(nil), "body")
+	assertEqual(t, tf.String(), expected)
+	tf.Apply(Semanticize(map[string]string{"u": "ins"}), "p")
+	assertEqual(t, tf.String(), "<html><head></head><body><p>"+
+		"<strong class=\"x\"><em>text</em></strong> <strong>already</strong> <ins>u</ins>"+
+		"</p></body></html>")
+}
+
+func TestFixVoidChildren(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body><p>a<br>d</p></body></html>")
+	tf := New(tree)
+	tf.Apply(AppendChildren(h5.Text("b"), h5.Element("img", nil, h5.Text("c"))), "br")
+	tf.Apply(FixVoidChildren(), "body")
+	assertEqual(t, tf.String(), "<html><head></head><body><p>a<br/>b<img/>cd</p></body></html>")
+}
+
+func TestCollapseRedundantWrappers(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<div class=\"outer\"> <div class=\"inner\"><div id=\"x\"><p>foo</p></div></div> </div>" +
+		"<div id=\"a\"><div id=\"b\">bar</div></div>" +
+		"<div><div>baz</div>text</div>" +
+		"</body></html>")
+	tf := New(tree)
+	expected := "<html><head></head><body>" +
+		"<div class=\"outer inner\" id=\"x\"><p>foo</p></div>" +
+		"<div id=\"a\"><div id=\"b\">bar</div></div>" +
+		"<div><div>baz</div>text</div>" +
+		"</body></html>"
+	if err := tf.CollapseRedundantWrappers("div"); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, tf.String(), expected)
+	if err := tf.CollapseRedundantWrappers("div"); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, tf.String(), expected)
+}
+
+func TestStripWhitespaceTextIn(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<select>\n  <option>a</option>\n  <option>b</option>\n</select>" +
+		"<p> <b>x</b> <i>y</i> </p>" +
+		"<div> <span>z</span> text </div>" +
+		"</body></html>")
+	tf := New(tree)
+	if err := tf.StripWhitespaceTextIn("select"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tf.StripWhitespaceTextIn("div"); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<select><option>a</option><option>b</option></select>"+
+		"<p> <b>x</b> <i>y</i> </p>"+
+		"<div><span>z</span> text </div>"+
+		"</body></html>")
+}
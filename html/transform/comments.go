@@ -0,0 +1,173 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"code.google.com/p/go-html-transform/h5"
+)
+
+const endifComment = "<![endif]"
+
+// conditionalComment parses the data of an IE conditional comment returning
+// the condition and the markup it hides. revealed is true for the start of a
+// downlevel-revealed conditional whose markup follows as sibling nodes.
+func conditionalComment(data string) (expr, inner string, revealed, ok bool) {
+	if !strings.HasPrefix(data, "[if ") {
+		return "", "", false, false
+	}
+	end := strings.Index(data, "]>")
+	if end < 0 {
+		return "", "", false, false
+	}
+	expr, rest := strings.TrimSpace(data[4:end]), data[end+2:]
+	switch {
+	case rest == "<!":
+		return expr, "", true, true
+	case strings.HasSuffix(rest, endifComment):
+		return expr, rest[:len(rest)-len(endifComment)], false, true
+	}
+	return "", "", false, false
+}
+
+// ExpandConditionalComments creates a TransformFunc that resolves the IE
+// conditional comments in a subtree. cond is called with the condition of
+// each comment, eg "lt IE 9", and if it returns true the markup inside the
+// comment is parsed and put in its place otherwise it is removed. For
+// downlevel-revealed conditionals the markup between the comments is kept
+// or removed the same way. A comment hiding only an <html> start tag adds
+// that tag's attributes to the document's <html> element instead, so apply
+// it to the Doc to handle those.
+//
+//	<!--[if IE]><p>ie</p><![endif]--> => <p>ie</p>
+func ExpandConditionalComments(cond func(expr string) bool) TransformFunc {
+	return func(n *html.Node) {
+		var comments []*html.Node
+		h5.WalkNodes(n, func(c *html.Node) {
+			if c.Type == html.CommentNode && c.Parent != nil {
+				comments = append(comments, c)
+			}
+		})
+		for _, c := range comments {
+			if c.Parent == nil {
+				// Removed along with a revealed conditional.
+				continue
+			}
+			expr, inner, revealed, ok := conditionalComment(c.Data)
+			if !ok {
+				continue
+			}
+			if revealed {
+				expandRevealed(c, cond(expr))
+				continue
+			}
+			if cond(expr) {
+				if attrs, ok := htmlStartTag(inner); ok {
+					setDocumentAttribs(c, attrs)
+					c.Parent.RemoveChild(c)
+					continue
+				}
+				// Comments outside of any element, like those before
+				// <html>, are parsed as if they were in the body.
+				context := c.Parent
+				if context.Type != html.ElementNode {
+					context = &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+				}
+				ns, err := html.ParseFragment(strings.NewReader(inner), context)
+				if err != nil {
+					continue
+				}
+				for _, nn := range ns {
+					c.Parent.InsertBefore(nn, c)
+				}
+			}
+			c.Parent.RemoveChild(c)
+		}
+	}
+}
+
+// htmlStartTag returns the attributes of markup that is only an <html>
+// start tag, as used to give old versions of IE a class on the root
+// element:
+//
+//	<!--[if lt IE 9]><html class="ie"><![endif]-->
+func htmlStartTag(markup string) ([]html.Attribute, bool) {
+	z := html.NewTokenizer(strings.NewReader(strings.TrimSpace(markup)))
+	if z.Next() != html.StartTagToken {
+		return nil, false
+	}
+	tok := z.Token()
+	if tok.DataAtom != atom.Html || z.Next() != html.ErrorToken {
+		return nil, false
+	}
+	return tok.Attr, true
+}
+
+// setDocumentAttribs adds attrs to the <html> element following the comment
+// c, combining classes.
+func setDocumentAttribs(c *html.Node, attrs []html.Attribute) {
+	for n := c.NextSibling; n != nil; n = n.NextSibling {
+		if !isElement(n, "html") {
+			continue
+		}
+		for _, a := range attrs {
+			if a.Key == "class" {
+				AddClass(strings.Fields(a.Val)...)(n)
+			} else {
+				ModifyAttrib(a.Key, a.Val)(n)
+			}
+		}
+		return
+	}
+}
+
+// expandRevealed removes the comments of the downlevel-revealed conditional
+// starting at start along with the markup between them unless keep is true.
+func expandRevealed(start *html.Node, keep bool) {
+	p := start.Parent
+	for c := start.NextSibling; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.CommentNode && c.Data == endifComment {
+			p.RemoveChild(c)
+			break
+		}
+		if !keep {
+			p.RemoveChild(c)
+		}
+		c = next
+	}
+	p.RemoveChild(start)
+}
+
+// RemoveCommentsExcept creates a TransformFunc that removes the comments in
+// the tree rooted at the node it operates on unless keep returns true for
+// their contents, eg to keep license comments.
+//
+//	t.Apply(RemoveCommentsExcept(func(data string) bool {
+//		return strings.Contains(data, "@license")
+//	}), "html")
+func RemoveCommentsExcept(keep func(data string) bool) TransformFunc {
+	return func(n *html.Node) {
+		var remove []*html.Node
+		h5.WalkNodes(n, func(c *html.Node) {
+			if c.Type == html.CommentNode && c.Parent != nil && !keep(c.Data) {
+				remove = append(remove, c)
+			}
+		})
+		for _, c := range remove {
+			c.Parent.RemoveChild(c)
+		}
+	}
+}
+
+// StripComments creates a TransformFunc that removes every comment in the
+// tree rooted at the node it operates on.
+func StripComments() TransformFunc {
+	return RemoveCommentsExcept(func(string) bool { return false })
+}
@@ -0,0 +1,73 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"strings"
+	"testing"
+
+	"code.google.com/p/go-html-transform/h5"
+)
+
+func TestExpandConditionalComments(t *testing.T) {
+	src := "<html><body>" +
+		"<!--[if IE]><p class=\"ie\">ie</p><![endif]-->" +
+		"<!--[if lt IE 9]><script src=\"shiv.js\"></script><![endif]-->" +
+		"<!--[if !IE]><!--><p>not ie</p><!--<![endif]-->" +
+		"<!-- plain -->" +
+		"</body></html>"
+	ie := func(expr string) bool { return expr == "IE" }
+
+	tree, _ := h5.NewFromString(src)
+	tf := New(tree)
+	tf.Apply(ExpandConditionalComments(ie), "html")
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<p class=\"ie\">ie</p>"+
+		"<!-- plain -->"+
+		"</body></html>")
+
+	tree, _ = h5.NewFromString(src)
+	tf = New(tree)
+	tf.Apply(ExpandConditionalComments(func(expr string) bool { return expr == "!IE" }), "html")
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<p>not ie</p>"+
+		"<!-- plain -->"+
+		"</body></html>")
+}
+
+func TestExpandConditionalCommentsDocumentLevel(t *testing.T) {
+	src := "<!--[if lt IE 9]><html class=\"ie old\"><![endif]-->" +
+		"<!--[if IE]><meta http-equiv=\"X-UA-Compatible\" content=\"IE=edge\"><![endif]-->" +
+		"<html lang=\"en\" class=\"no-js\"><body></body></html>"
+	tree, _ := h5.NewFromString(src)
+	tf := New(tree)
+	ExpandConditionalComments(func(string) bool { return true })(tf.Doc())
+	assertEqual(t, tf.String(), "<meta http-equiv=\"X-UA-Compatible\" content=\"IE=edge\"/>"+
+		"<html lang=\"en\" class=\"no-js ie old\"><head></head><body></body></html>")
+
+	tree, _ = h5.NewFromString(src)
+	tf = New(tree)
+	ExpandConditionalComments(func(string) bool { return false })(tf.Doc())
+	assertEqual(t, tf.String(),
+		"<html lang=\"en\" class=\"no-js\"><head></head><body></body></html>")
+}
+
+func TestRemoveCommentsExcept(t *testing.T) {
+	src := "<html><head><!-- @license MIT --></head><body>" +
+		"<!-- a --><!-- b --><p>x<!-- c --></p><!-- build:js -->" +
+		"</body></html>"
+	tree, _ := h5.NewFromString(src)
+	tf := New(tree)
+	tf.Apply(RemoveCommentsExcept(func(data string) bool {
+		return strings.Contains(data, "@license")
+	}), "html")
+	assertEqual(t, tf.String(), "<html><head><!-- @license MIT --></head><body>"+
+		"<p>x</p>"+
+		"</body></html>")
+	tree, _ = h5.NewFromString(src)
+	tf = New(tree)
+	tf.Apply(StripComments(), "html")
+	assertEqual(t, tf.String(), "<html><head></head><body><p>x</p></body></html>")
+}
@@ -0,0 +1,233 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"code.google.com/p/go-html-transform/css/selector"
+	"code.google.com/p/go-html-transform/h5"
+)
+
+// text returns the text content of n with runs of whitespace collapsed
+// to a single space and surrounding whitespace removed.
+func text(n *html.Node) string {
+	return strings.Join(strings.Fields(h5.TextContent(n)), " ")
+}
+
+// first returns the first node matched by the CSS3 selector in the tree
+// rooted at n or nil if there isn't one.
+func first(n *html.Node, sel string) (*html.Node, error) {
+	chn, err := selector.Selector(sel)
+	if err != nil {
+		return nil, err
+	}
+	if ns := chn.Find(n); len(ns) > 0 {
+		return ns[0], nil
+	}
+	return nil, nil
+}
+
+// ExtractTable returns the text of the cells in the first table matched by
+// the CSS3 selector as a slice of rows. Rows from thead, tbody and tfoot are
+// included in document order; nested tables and non cell content are
+// skipped. Colspan and rowspan are ignored so each cell takes up a single
+// slot in its row.
+func (t *Transformer) ExtractTable(sel string) ([][]string, error) {
+	table, err := first(t.root(), sel)
+	if err != nil {
+		return nil, err
+	}
+	if table == nil || !isElement(table, "table") {
+		return nil, fmt.Errorf("No table matched %q", sel)
+	}
+	var rows [][]string
+	var addRows func(n *html.Node)
+	addRows = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch {
+			case isElement(c, "thead"), isElement(c, "tbody"), isElement(c, "tfoot"):
+				addRows(c)
+			case isElement(c, "tr"):
+				row := []string{}
+				for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+					if isElement(cell, "td") || isElement(cell, "th") {
+						row = append(row, text(cell))
+					}
+				}
+				rows = append(rows, row)
+			}
+		}
+	}
+	addRows(table)
+	return rows, nil
+}
+
+// FormOption is an <option> of a select FormField.
+type FormOption struct {
+	Value, Label string
+	Selected     bool
+}
+
+// FormField describes an input, select or textarea of a form.
+type FormField struct {
+	Name string
+	// Type is the input type, defaulting to "text", or "select" or
+	// "textarea" for those elements.
+	Type string
+	// Value is the initial value. For a select it is the value of the first
+	// selected option, or of the first option if none is selected.
+	Value    string
+	Required bool
+	// Options are the options of a select in document order.
+	Options []FormOption
+}
+
+// FormSpec describes a form as returned by ExtractForm.
+type FormSpec struct {
+	Action string
+	// Method is the lowercased method, defaulting to "get".
+	Method string
+	Fields []FormField
+}
+
+// formOption returns the FormOption for an <option> element.
+func formOption(n *html.Node) FormOption {
+	opt := FormOption{Label: text(n)}
+	var ok bool
+	if opt.Value, ok = getAttr(n, "value"); !ok {
+		opt.Value = opt.Label
+	}
+	_, opt.Selected = getAttr(n, "selected")
+	return opt
+}
+
+// ExtractForm describes the first form matched by the CSS3 selector and its
+// fields in document order. Matches that aren't forms are skipped. It
+// returns an error if the selector doesn't match a form.
+func (t *Transformer) ExtractForm(sel string) (FormSpec, error) {
+	chn, err := selector.Selector(sel)
+	if err != nil {
+		return FormSpec{}, err
+	}
+	var form *html.Node
+	for _, n := range chn.Find(t.root()) {
+		if isElement(n, "form") {
+			form = n
+			break
+		}
+	}
+	if form == nil {
+		return FormSpec{}, fmt.Errorf("No form matched %q", sel)
+	}
+	spec := FormSpec{Method: "get"}
+	spec.Action, _ = getAttr(form, "action")
+	if method, ok := getAttr(form, "method"); ok {
+		spec.Method = strings.ToLower(strings.TrimSpace(method))
+	}
+	h5.WalkNodes(form, func(n *html.Node) {
+		if n.Type != html.ElementNode {
+			return
+		}
+		var field FormField
+		switch h5.Data(n) {
+		case "input":
+			field.Type = "text"
+			if typ, ok := getAttr(n, "type"); ok {
+				field.Type = strings.ToLower(strings.TrimSpace(typ))
+			}
+			field.Value, _ = getAttr(n, "value")
+		case "textarea":
+			field.Type = "textarea"
+			field.Value = h5.TextContent(n)
+		case "select":
+			field.Type = "select"
+			h5.WalkNodes(n, func(c *html.Node) {
+				if isElement(c, "option") {
+					field.Options = append(field.Options, formOption(c))
+				}
+			})
+			if len(field.Options) > 0 {
+				field.Value = field.Options[0].Value
+			}
+			for _, opt := range field.Options {
+				if opt.Selected {
+					field.Value = opt.Value
+					break
+				}
+			}
+		default:
+			return
+		}
+		field.Name, _ = getAttr(n, "name")
+		_, field.Required = getAttr(n, "required")
+		spec.Fields = append(spec.Fields, field)
+	})
+	return spec, nil
+}
+
+// ExtractJSONLD returns the contents of every
+// <script type="application/ld+json"> element in document order, as written,
+// for the caller to decode. It returns an empty slice if there aren't any.
+func (t *Transformer) ExtractJSONLD() []string {
+	found := []string{}
+	h5.WalkNodes(t.root(), func(n *html.Node) {
+		if !isElement(n, "script") {
+			return
+		}
+		typ, _ := getAttr(n, "type")
+		if strings.EqualFold(strings.TrimSpace(typ), "application/ld+json") {
+			found = append(found, h5.TextContent(n))
+		}
+	})
+	return found
+}
+
+// invisibleElements are the elements whose content is never displayed.
+var invisibleElements = map[string]bool{
+	"head":     true,
+	"script":   true,
+	"style":    true,
+	"template": true,
+	"noscript": true,
+}
+
+// FullText returns the visible text of the document in document order for
+// indexing. The content of the <head>, scripts, styles, templates and
+// elements hidden by their inline style is skipped. Block elements and <br>
+// separate words so runs of whitespace are collapsed to a single space.
+func (t *Transformer) FullText() string {
+	var buf bytes.Buffer
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			buf.WriteString(n.Data)
+			return
+		case html.ElementNode:
+			if invisibleElements[h5.Data(n)] || IsHidden(n) {
+				return
+			}
+		}
+		block := n.Type == html.ElementNode && !isInline(n) || isElement(n, "br")
+		if block {
+			buf.WriteByte(' ')
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if block {
+			buf.WriteByte(' ')
+		}
+	}
+	if n := t.root(); n != nil {
+		walk(n)
+	}
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
@@ -0,0 +1,119 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"reflect"
+	"testing"
+
+	"code.google.com/p/go-html-transform/h5"
+)
+
+func TestExtractTable(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body><table id=\"prices\">" +
+		"<caption>Prices</caption>" +
+		"<thead><tr><th>Item</th><th>Price</th></tr></thead>" +
+		"<tbody><tr><td> Apple\n pie </td><td>3</td></tr>" +
+		"<tr><td>Tea</td><td><table><tr><td>1</td></tr></table></td></tr></tbody>" +
+		"</table></body></html>")
+	tf := New(tree)
+	rows, err := tf.ExtractTable("table#prices")
+	if err != nil {
+		t.Fatalf("ExtractTable failed %s", err)
+	}
+	expected := [][]string{
+		{"Item", "Price"},
+		{"Apple pie", "3"},
+		{"Tea", "1"},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("Got: %q Expected: %q", rows, expected)
+	}
+	if _, err := tf.ExtractTable("ul"); err == nil {
+		t.Errorf("ExtractTable didn't return an error when nothing matched")
+	}
+}
+
+func TestExtractJSONLD(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><head>" +
+		"<script type=\"application/ld+json\">{\"name\": \"a < b\"}</script>" +
+		"<script>var x = 1;</script>" +
+		"</head><body>" +
+		"<script type=\"Application/LD+JSON\">[1, 2]</script>" +
+		"</body></html>")
+	tf := New(tree)
+	got := tf.ExtractJSONLD()
+	expected := []string{"{\"name\": \"a < b\"}", "[1, 2]"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Got: %q Expected: %q", got, expected)
+	}
+	tree, _ = h5.NewFromString("<html><body><p>none</p></body></html>")
+	if got := New(tree).ExtractJSONLD(); got == nil || len(got) != 0 {
+		t.Errorf("Expected an empty slice got %#v", got)
+	}
+}
+
+func TestFullText(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><head><title>Title</title>" +
+		"<style>p { color: red }</style></head><body>" +
+		"<h1>Heading</h1><p>First <em>para</em>graph.</p><p>Second</p>" +
+		"<script>var hidden = 1;</script>" +
+		"<div style=\"display: none\">secret</div>" +
+		"<ul><li>one</li><li>two<br>lines</li></ul>" +
+		"<template><p>inert</p></template>" +
+		"</body></html>")
+	tf := New(tree)
+	assertEqual(t, tf.FullText(), "Heading First paragraph. Second one two lines")
+}
+
+func TestExtractForm(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<div id=\"wrap\"><form id=\"signup\" action=\"/join\" method=\"POST\">" +
+		"<input name=\"email\" type=\"email\" required>" +
+		"<input name=\"nick\">" +
+		"<input type=\"hidden\" name=\"token\" value=\"abc\">" +
+		"<fieldset><select name=\"plan\">" +
+		"<option value=\"free\">Free</option>" +
+		"<optgroup label=\"Paid\"><option value=\"pro\" selected>Pro</option>" +
+		"<option>Team</option></optgroup>" +
+		"</select></fieldset>" +
+		"<select name=\"size\"><option>S</option><option>M</option></select>" +
+		"<textarea name=\"bio\">Hi there</textarea>" +
+		"</form></div></body></html>")
+	tf := New(tree)
+	spec, err := tf.ExtractForm("#signup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := FormSpec{
+		Action: "/join",
+		Method: "post",
+		Fields: []FormField{
+			{Name: "email", Type: "email", Required: true},
+			{Name: "nick", Type: "text"},
+			{Name: "token", Type: "hidden", Value: "abc"},
+			{Name: "plan", Type: "select", Value: "pro", Options: []FormOption{
+				{"free", "Free", false},
+				{"pro", "Pro", true},
+				{"Team", "Team", false},
+			}},
+			{Name: "size", Type: "select", Value: "S", Options: []FormOption{
+				{"S", "S", false},
+				{"M", "M", false},
+			}},
+			{Name: "bio", Type: "textarea", Value: "Hi there"},
+		},
+	}
+	if !reflect.DeepEqual(spec, expected) {
+		t.Errorf("Expected %+v got %+v", expected, spec)
+	}
+	// A wrapper matched before the form is skipped.
+	if wrapped, err := tf.ExtractForm("[id]"); err != nil || !reflect.DeepEqual(wrapped, expected) {
+		t.Errorf("Expected %+v got %+v, %v", expected, wrapped, err)
+	}
+	if _, err := tf.ExtractForm("textarea"); err == nil {
+		t.Errorf("Expected an error for a selector that doesn't match a form")
+	}
+}
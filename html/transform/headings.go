@@ -0,0 +1,120 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"strconv"
+
+	"golang.org/x/net/html"
+
+	"code.google.com/p/go-html-transform/h5"
+)
+
+// headingLevel returns the level of an h1-h6 element or 0 if n isn't a
+// heading.
+func headingLevel(n *html.Node) int {
+	if n.Type != html.ElementNode {
+		return 0
+	}
+	tag := h5.Data(n)
+	if len(tag) == 2 && (tag[0] == 'h' || tag[0] == 'H') &&
+		'1' <= tag[1] && tag[1] <= '6' {
+		return int(tag[1] - '0')
+	}
+	return 0
+}
+
+func headingTag(level int) string {
+	if level < 1 {
+		level = 1
+	} else if level > 6 {
+		level = 6
+	}
+	return "h" + strconv.Itoa(level)
+}
+
+// ShiftHeadings creates a TransformFunc that changes the level of every
+// heading in the tree rooted at the node it operates on by delta. Levels are
+// clamped to h1-h6. Attributes and children are preserved.
+//
+//	t.Apply(ShiftHeadings(1), "article")
+func ShiftHeadings(delta int) TransformFunc {
+	return func(n *html.Node) {
+		h5.WalkNodes(n, func(n *html.Node) {
+			if l := headingLevel(n); l > 0 {
+				Rename(headingTag(l + delta))(n)
+			}
+		})
+	}
+}
+
+// NormalizeHeadingSequence creates a TransformFunc that renames the headings
+// in the tree rooted at the node it operates on so that, in document order,
+// none is more than one level deeper than the section it is in. Headings
+// that had the same level under the same parent heading still share a
+// level, so the relative hierarchy is preserved. The first heading keeps its
+// level. Attributes and children are preserved.
+//
+//	<h2/><h4/><h4/><h5/><h3/> => <h2/><h3/><h3/><h4/><h3/>
+func NormalizeHeadingSequence() TransformFunc {
+	// level maps the level a heading had to the one it was given.
+	type level struct{ orig, renamed int }
+	return func(n *html.Node) {
+		var open []level
+		h5.WalkNodes(n, func(n *html.Node) {
+			l := headingLevel(n)
+			if l == 0 {
+				return
+			}
+			for len(open) > 0 && open[len(open)-1].orig > l {
+				open = open[:len(open)-1]
+			}
+			renamed := l
+			if len(open) > 0 {
+				top := open[len(open)-1]
+				if top.orig == l {
+					renamed = top.renamed
+					open = open[:len(open)-1]
+				} else {
+					renamed = top.renamed + 1
+				}
+			}
+			if renamed != l {
+				Rename(headingTag(renamed))(n)
+			}
+			open = append(open, level{l, renamed})
+		})
+	}
+}
+
+// IndexEntry is a heading in the list returned by BuildPageIndex.
+type IndexEntry struct {
+	// Level is 1 for an h1 through 6 for an h6.
+	Level int
+	// Text is the text of the heading with whitespace collapsed.
+	Text string
+	// ID is the id of the heading element.
+	ID string
+}
+
+// BuildPageIndex returns every heading in the document in order so an index
+// or table of contents can be built from it. Headings without an id are
+// given one generated from their text the way AddSlugIds does, so every
+// entry can be linked to. A document without headings returns an empty
+// list.
+func (t *Transformer) BuildPageIndex() []IndexEntry {
+	entries := []IndexEntry{}
+	root := t.root()
+	if root == nil {
+		return entries
+	}
+	ids := documentIds(t.Doc())
+	h5.WalkNodes(root, func(n *html.Node) {
+		if level := headingLevel(n); level > 0 {
+			entries = append(entries, IndexEntry{level, text(n), ids.ensure(n)})
+		}
+	})
+	return entries
+}
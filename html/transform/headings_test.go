@@ -0,0 +1,78 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"reflect"
+	"testing"
+
+	"code.google.com/p/go-html-transform/h5"
+)
+
+func TestShiftHeadings(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body><h1>top</h1><article>" +
+		"<h1 id=\"a\">one</h1><h3>three<em>!</em></h3><h6>six</h6>" +
+		"</article></body></html>")
+	tf := New(tree)
+	tf.Apply(ShiftHeadings(1), "article")
+	assertEqual(t, tf.String(), "<html><head></head><body><h1>top</h1><article>"+
+		"<h2 id=\"a\">one</h2><h4>three<em>!</em></h4><h6>six</h6>"+
+		"</article></body></html>")
+	tf.Apply(ShiftHeadings(-3), "article")
+	assertEqual(t, tf.String(), "<html><head></head><body><h1>top</h1><article>"+
+		"<h1 id=\"a\">one</h1><h1>three<em>!</em></h1><h3>six</h3>"+
+		"</article></body></html>")
+}
+
+func TestNormalizeHeadingSequence(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<h2>a</h2><h4 id=\"b\">b<em>!</em></h4><h3>c</h3><h6>d</h6><h2>e</h2>" +
+		"</body></html>")
+	tf := New(tree)
+	tf.Apply(NormalizeHeadingSequence(), "body")
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<h2>a</h2><h3 id=\"b\">b<em>!</em></h3><h3>c</h3><h4>d</h4><h2>e</h2>"+
+		"</body></html>")
+
+	// Sibling headings that skip a level stay siblings.
+	tree, _ = h5.NewFromString("<html><body>" +
+		"<h2>a</h2><h4>b</h4><h4>c</h4><h5>d</h5><h3>e</h3>" +
+		"</body></html>")
+	tf = New(tree)
+	tf.Apply(NormalizeHeadingSequence(), "body")
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<h2>a</h2><h3>b</h3><h3>c</h3><h4>d</h4><h3>e</h3>"+
+		"</body></html>")
+}
+
+func TestBuildPageIndex(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<h1>Guide</h1><p>intro</p>" +
+		"<section><h2 id=\"setup\">Getting   <em>set up</em></h2>" +
+		"<h3>Install</h3></section>" +
+		"<h2>Install</h2>" +
+		"</body></html>")
+	tf := New(tree)
+	expected := []IndexEntry{
+		{1, "Guide", "guide"},
+		{2, "Getting set up", "setup"},
+		{3, "Install", "install"},
+		{2, "Install", "install-1"},
+	}
+	if entries := tf.BuildPageIndex(); !reflect.DeepEqual(entries, expected) {
+		t.Errorf("Expected %v got %v", expected, entries)
+	}
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<h1 id=\"guide\">Guide</h1><p>intro</p>"+
+		"<section><h2 id=\"setup\">Getting   <em>set up</em></h2>"+
+		"<h3 id=\"install\">Install</h3></section>"+
+		"<h2 id=\"install-1\">Install</h2>"+
+		"</body></html>")
+
+	tree, _ = h5.NewFromString("<html><body><p>none</p></body></html>")
+	if entries := New(tree).BuildPageIndex(); entries == nil || len(entries) != 0 {
+		t.Errorf("Expected an empty index got %v", entries)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"code.google.com/p/go-html-transform/h5"
+)
+
+// Localize replaces the text of every element carrying the attribute attr,
+// like data-i18n="greeting", with the catalog entry for its key. Attributes
+// can be localized as well with attr followed by a dash and the attribute
+// name, so data-i18n-title="tip" sets the title to catalog["tip"].
+//
+//	t.Localize(catalogs["fr"], "data-i18n")
+//
+// Elements and attributes whose key isn't in the catalog are left as they
+// are. The missing keys are returned in document order so they can be
+// reported.
+func (t *Transformer) Localize(catalog map[string]string, attr string) []string {
+	var missing []string
+	lookup := func(key string) (string, bool) {
+		msg, ok := catalog[key]
+		if !ok {
+			missing = append(missing, key)
+		}
+		return msg, ok
+	}
+	prefix := attr + "-"
+	h5.WalkNodes(t.root(), func(n *html.Node) {
+		if n.Type != html.ElementNode {
+			return
+		}
+		if key, ok := getAttr(n, attr); ok {
+			if msg, ok := lookup(key); ok {
+				ReplaceChildren(h5.Text(msg))(n)
+			}
+		}
+		// Attributes ModifyAttrib appends aren't visited by the range.
+		for _, a := range n.Attr {
+			name := strings.TrimPrefix(a.Key, prefix)
+			if name == a.Key || name == "" {
+				continue
+			}
+			if msg, ok := lookup(a.Val); ok {
+				ModifyAttrib(name, msg)(n)
+			}
+		}
+	})
+	return missing
+}
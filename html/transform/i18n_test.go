@@ -0,0 +1,37 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"reflect"
+	"testing"
+
+	"code.google.com/p/go-html-transform/h5"
+)
+
+func TestLocalize(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<h1 data-i18n=\"title\">Welcome</h1>" +
+		"<p>Unkeyed <b>content</b></p>" +
+		"<p data-i18n=\"lost\">Fallback</p>" +
+		"<img src=\"a.png\" alt=\"A cat\" data-i18n-alt=\"cat\">" +
+		"<span data-i18n-=\"cat\">x</span>" +
+		"</body></html>")
+	tf := New(tree)
+	missing := tf.Localize(map[string]string{
+		"title": "Bienvenue",
+		"cat":   "Un chat",
+	}, "data-i18n")
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<h1 data-i18n=\"title\">Bienvenue</h1>"+
+		"<p>Unkeyed <b>content</b></p>"+
+		"<p data-i18n=\"lost\">Fallback</p>"+
+		"<img src=\"a.png\" alt=\"Un chat\" data-i18n-alt=\"cat\"/>"+
+		"<span data-i18n-=\"cat\">x</span>"+
+		"</body></html>")
+	if !reflect.DeepEqual(missing, []string{"lost"}) {
+		t.Errorf("Expected missing keys [lost] got %v", missing)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+
+	"code.google.com/p/go-html-transform/css/selector"
+	"code.google.com/p/go-html-transform/h5"
+)
+
+// idSet tracks the ids in use in a document so that generated ids don't
+// collide with existing ones or each other.
+type idSet map[string]struct{}
+
+func documentIds(n *html.Node) idSet {
+	ids := idSet{}
+	h5.WalkNodes(n, func(n *html.Node) {
+		if n.Type != html.ElementNode {
+			return
+		}
+		if id, ok := getAttr(n, "id"); ok && id != "" {
+			ids[id] = struct{}{}
+		}
+	})
+	return ids
+}
+
+// unique reserves and returns base if it isn't in use yet, or base with the
+// first free numeric suffix otherwise.
+func (ids idSet) unique(base string) string {
+	id := base
+	for i := 1; ; i++ {
+		if _, ok := ids[id]; !ok {
+			break
+		}
+		id = base + "-" + strconv.Itoa(i)
+	}
+	ids[id] = struct{}{}
+	return id
+}
+
+// ensure returns the id of n giving it a unique one generated from its text
+// content first if it has none. Elements with no usable text get a
+// "section" based id.
+func (ids idSet) ensure(n *html.Node) string {
+	if id, ok := getAttr(n, "id"); ok && id != "" {
+		return id
+	}
+	slug := slugify(h5.TextContent(n))
+	if slug == "" {
+		slug = "section"
+	}
+	id := ids.unique(slug)
+	ModifyAttrib("id", id)(n)
+	return id
+}
+
+// slugify turns s into a lowercase URL friendly string made up of letters
+// and digits separated by single dashes.
+func slugify(s string) string {
+	var words []string
+	word := []rune{}
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			word = append(word, r)
+		} else if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return strings.Join(words, "-")
+}
+
+// AddSlugIds gives every element matched by the CSS3 selector that lacks an
+// id an id generated from its text content. Generated ids never collide with
+// ids already in the document; collisions get a numeric suffix.
+//
+//	<h2>Intro</h2><h2>Intro</h2> => <h2 id="intro">...<h2 id="intro-1">
+//
+// Elements with no usable text get a "section" based id.
+func (t *Transformer) AddSlugIds(sel string) error {
+	chn, err := selector.Selector(sel)
+	if err != nil {
+		return err
+	}
+	ids := documentIds(t.Doc())
+	t.ApplyWithCollector(func(n *html.Node) {
+		ids.ensure(n)
+	}, chn)
+	return nil
+}
+
+// AddParagraphIds gives every p in the document that lacks an id one made
+// from prefix and its position among the paragraphs, counting from 1, so
+// any paragraph can be linked to. Numbering by position keeps the ids the
+// same across runs over the same document. An id that is already in use
+// gets a numeric suffix like AddSlugIds does.
+//
+//	<p>a</p><p id="x">b</p><p>c</p> => <p id="para-1">a</p><p id="x">b</p><p id="para-3">c</p>
+func (t *Transformer) AddParagraphIds(prefix string) {
+	ids := documentIds(t.Doc())
+	t.ApplyIndexed(func(i int, n *html.Node) {
+		if id, ok := getAttr(n, "id"); ok && id != "" {
+			return
+		}
+		ModifyAttrib("id", ids.unique(prefix+"-"+strconv.Itoa(i+1)))(n)
+	}, "p")
+}
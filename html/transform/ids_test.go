@@ -0,0 +1,55 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"testing"
+
+	"code.google.com/p/go-html-transform/h5"
+)
+
+func TestSlugify(t *testing.T) {
+	assertEqual(t, slugify("  Hello, World! "), "hello-world")
+	assertEqual(t, slugify("Go 1.2 -- Release Notes"), "go-1-2-release-notes")
+	assertEqual(t, slugify("?!"), "")
+}
+
+func TestAddSlugIds(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<h2>Getting Started</h2>" +
+		"<h2 id=\"intro\">Custom</h2>" +
+		"<h2>Intro</h2>" +
+		"<h2>Getting <em>started</em></h2>" +
+		"</body></html>")
+	tf := New(tree)
+	if err := tf.AddSlugIds("h2"); err != nil {
+		t.Fatalf("AddSlugIds failed %s", err)
+	}
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<h2 id=\"getting-started\">Getting Started</h2>"+
+		"<h2 id=\"intro\">Custom</h2>"+
+		"<h2 id=\"intro-1\">Intro</h2>"+
+		"<h2 id=\"getting-started-1\">Getting <em>started</em></h2>"+
+		"</body></html>")
+}
+
+func TestAddParagraphIds(t *testing.T) {
+	doc := "<html><body>" +
+		"<p>a</p><p id=\"intro\">b</p><div><p>c</p></div>" +
+		"<p></p><span id=\"para-4\">taken</span>" +
+		"</body></html>"
+	expected := "<html><head></head><body>" +
+		"<p id=\"para-1\">a</p><p id=\"intro\">b</p><div><p id=\"para-3\">c</p></div>" +
+		"<p id=\"para-4-1\"></p><span id=\"para-4\">taken</span>" +
+		"</body></html>"
+	for i := 0; i < 2; i++ {
+		tree, _ := h5.NewFromString(doc)
+		tf := New(tree)
+		tf.AddParagraphIds("para")
+		assertEqual(t, tf.String(), expected)
+		tf.AddParagraphIds("para")
+		assertEqual(t, tf.String(), expected)
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"code.google.com/p/go-html-transform/h5"
+)
+
+// setDefaultAttrib sets the attribute on n unless it already has one.
+func setDefaultAttrib(n *html.Node, key, val string) {
+	if _, ok := getAttr(n, key); !ok {
+		ModifyAttrib(key, val)(n)
+	}
+}
+
+// OptimizeImages adds loading="lazy" and decoding="async" to every img
+// except the first skipFirst in document order, which are likely to be
+// above the fold where lazy loading delays the largest contentful paint.
+// Pass 0 to add the hints to every image. Images that already have either
+// attribute keep their value.
+func (t *Transformer) OptimizeImages(skipFirst int) {
+	t.ApplyIndexed(func(i int, n *html.Node) {
+		if i < skipFirst {
+			return
+		}
+		setDefaultAttrib(n, "loading", "lazy")
+		setDefaultAttrib(n, "decoding", "async")
+	}, "img")
+}
+
+// MarkLCPImage marks the first image matched by the CSS3 selector, usually
+// the hero image that is the largest contentful paint, to be fetched early
+// with fetchpriority="high" and loading="eager". A decoding="async" hint,
+// like the ones OptimizeImages adds, is removed. It does nothing if nothing
+// matches.
+//
+//	t.MarkLCPImage("header img")
+func (t *Transformer) MarkLCPImage(sel string) error {
+	return t.ApplyFirst(func(n *html.Node) {
+		ModifyAttrib("fetchpriority", "high")(n)
+		ModifyAttrib("loading", "eager")(n)
+		if v, _ := getAttr(n, "decoding"); v == "async" {
+			removeAttr(n, "decoding")
+		}
+	}, sel)
+}
+
+// SetSizes sets the sizes attribute of every img in the document that has a
+// srcset but no sizes and that pred returns true for. Without sizes the
+// browser assumes the image fills the viewport when picking a candidate from
+// srcset.
+//
+//	t.SetSizes(HasClass("thumb"), "(max-width: 600px) 50vw, 200px")
+func (t *Transformer) SetSizes(pred func(*html.Node) bool, sizes string) {
+	t.SetSizesFunc(func(n *html.Node) string {
+		if !pred(n) {
+			return ""
+		}
+		return sizes
+	})
+}
+
+// SetSizesFunc is like SetSizes but computes the sizes for each img with f.
+// Images f returns "" for are left alone.
+func (t *Transformer) SetSizesFunc(f func(*html.Node) string) {
+	t.Apply(func(n *html.Node) {
+		if sizes := f(n); sizes != "" {
+			ModifyAttrib("sizes", sizes)(n)
+		}
+	}, "img[srcset]:not([sizes])")
+}
+
+// Figurify wraps each img matched by the CSS3 selector in a <figure>. If sel
+// is empty the images that are the only element in their parent, apart
+// from whitespace, are wrapped. An image with a title, or failing that an
+// alt, gets a <figcaption> with that text after it. A <p> can't contain a
+// figure so an image in a <p> is moved out of it, splitting the paragraph
+// around the figure and dropping any part left empty. Both parts keep the
+// paragraph's attributes but only the first one left keeps its id. Images
+// already in a figure, or inside a link or other inline element, are left
+// alone.
+//
+//	<p><img src="a.png" alt="A cat"></p> => <figure><img .../><figcaption>A cat</figcaption></figure>
+//	<p>a <img src="b.png"> b</p> => <p>a </p><figure><img .../></figure><p> b</p>
+func (t *Transformer) Figurify(sel string) error {
+	standalone := sel == ""
+	if standalone {
+		sel = "img"
+	}
+	return t.Apply(func(n *html.Node) {
+		if !isElement(n, "img") || n.Parent == nil {
+			return
+		}
+		if standalone && !onlyElementChild(n) {
+			return
+		}
+		if fig, _ := Closest(n, "figure"); fig != nil {
+			return
+		}
+		if isInline(n.Parent) {
+			return
+		}
+		if p := n.Parent; isElement(p, "p") {
+			if p.Parent == nil {
+				return
+			}
+			rest := h5.SplitAt(p, n)
+			rest.RemoveChild(n)
+			p.Parent.InsertBefore(n, rest)
+			if isBlank(p) {
+				p.Parent.RemoveChild(p)
+			} else {
+				// The copy can't share the paragraph's id.
+				removeAttr(rest, "id")
+			}
+			if isBlank(rest) {
+				rest.Parent.RemoveChild(rest)
+			}
+		}
+		Wrap(h5.Element("figure", nil))(n)
+		caption, ok := getAttr(n, "title")
+		if !ok || strings.TrimSpace(caption) == "" {
+			caption, _ = getAttr(n, "alt")
+		}
+		if caption = strings.TrimSpace(caption); caption != "" {
+			n.Parent.AppendChild(h5.Element("figcaption", nil, h5.Text(caption)))
+		}
+	}, sel)
+}
+
+// mediaSrc returns the src of an iframe or video. A video without a src
+// uses the src of its first <source>.
+func mediaSrc(n *html.Node) (string, bool) {
+	if src, ok := getAttr(n, "src"); ok {
+		return src, true
+	}
+	if !isElement(n, "video") {
+		return "", false
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if isElement(c, "source") {
+			return getAttr(c, "src")
+		}
+	}
+	return "", false
+}
+
+// SetMediaDimensions creates a TransformFunc that sets the width and height
+// of an <iframe> or <video> to the size resolve returns for its src, so the
+// browser can reserve space for it before it loads. Elements that already
+// have a width or height, or whose src resolve doesn't know, are left
+// alone.
+//
+//	t.Apply(SetMediaDimensions(embeds.Size), "iframe")
+//	t.Apply(SetMediaDimensions(embeds.Size), "video")
+func SetMediaDimensions(resolve func(src string) (w, h int, ok bool)) TransformFunc {
+	return func(n *html.Node) {
+		if !isElement(n, "iframe") && !isElement(n, "video") {
+			return
+		}
+		if HasAttrib("width")(n) || HasAttrib("height")(n) {
+			return
+		}
+		src, ok := mediaSrc(n)
+		if !ok {
+			return
+		}
+		if w, h, ok := resolve(src); ok {
+			ModifyAttrib("width", strconv.Itoa(w))(n)
+			ModifyAttrib("height", strconv.Itoa(h))(n)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"testing"
+
+	"golang.org/x/net/html"
+
+	"code.google.com/p/go-html-transform/h5"
+)
+
+func TestOptimizeImages(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<img src=\"hero.png\"><p><img src=\"a.png\"></p>" +
+		"<img src=\"b.png\" loading=\"eager\">" +
+		"</body></html>")
+	tf := New(tree)
+	tf.OptimizeImages(1)
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<img src=\"hero.png\"/>"+
+		"<p><img src=\"a.png\" loading=\"lazy\" decoding=\"async\"/></p>"+
+		"<img src=\"b.png\" loading=\"eager\" decoding=\"async\"/>"+
+		"</body></html>")
+	tf.OptimizeImages(0)
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<img src=\"hero.png\" loading=\"lazy\" decoding=\"async\"/>"+
+		"<p><img src=\"a.png\" loading=\"lazy\" decoding=\"async\"/></p>"+
+		"<img src=\"b.png\" loading=\"eager\" decoding=\"async\"/>"+
+		"</body></html>")
+}
+
+func TestMarkLCPImage(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<header><img src=\"hero.png\" loading=\"lazy\" decoding=\"async\"></header>" +
+		"<img src=\"a.png\" loading=\"lazy\">" +
+		"</body></html>")
+	tf := New(tree)
+	if err := tf.MarkLCPImage("img"); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if err := tf.MarkLCPImage("footer img"); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<header><img src=\"hero.png\" loading=\"eager\" fetchpriority=\"high\"/></header>"+
+		"<img src=\"a.png\" loading=\"lazy\"/>"+
+		"</body></html>")
+}
+
+func TestSetMediaDimensions(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<iframe src=\"https://video.example/embed/1\"></iframe>" +
+		"<iframe src=\"https://video.example/embed/1\" width=\"100\"></iframe>" +
+		"<iframe src=\"https://unknown.example/\"></iframe>" +
+		"<video controls><source src=\"clip.mp4\" type=\"video/mp4\"></video>" +
+		"</body></html>")
+	tf := New(tree)
+	sizes := map[string][2]int{
+		"https://video.example/embed/1": {560, 315},
+		"clip.mp4":                      {1280, 720},
+	}
+	f := SetMediaDimensions(func(src string) (int, int, bool) {
+		s, ok := sizes[src]
+		return s[0], s[1], ok
+	})
+	tf.Apply(f, "iframe")
+	tf.Apply(f, "video")
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<iframe src=\"https://video.example/embed/1\" width=\"560\" height=\"315\"></iframe>"+
+		"<iframe src=\"https://video.example/embed/1\" width=\"100\"></iframe>"+
+		"<iframe src=\"https://unknown.example/\"></iframe>"+
+		"<video controls=\"\" width=\"1280\" height=\"720\"><source src=\"clip.mp4\" type=\"video/mp4\"/></video>"+
+		"</body></html>")
+}
+
+func TestSetSizes(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<img src=\"a.png\" srcset=\"a-2x.png 2x\" class=\"thumb\">" +
+		"<img src=\"b.png\" srcset=\"b-2x.png 2x\" class=\"thumb\" sizes=\"10vw\">" +
+		"<img src=\"c.png\" class=\"thumb\">" +
+		"<img src=\"d.png\" srcset=\"d-2x.png 2x\">" +
+		"</body></html>")
+	tf := New(tree)
+	tf.SetSizes(HasClass("thumb"), "200px")
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<img src=\"a.png\" srcset=\"a-2x.png 2x\" class=\"thumb\" sizes=\"200px\"/>"+
+		"<img src=\"b.png\" srcset=\"b-2x.png 2x\" class=\"thumb\" sizes=\"10vw\"/>"+
+		"<img src=\"c.png\" class=\"thumb\"/>"+
+		"<img src=\"d.png\" srcset=\"d-2x.png 2x\"/>"+
+		"</body></html>")
+	tf.SetSizesFunc(func(n *html.Node) string {
+		src, _ := getAttr(n, "src")
+		return "(max-width: 600px) 100vw, " + src
+	})
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<img src=\"a.png\" srcset=\"a-2x.png 2x\" class=\"thumb\" sizes=\"200px\"/>"+
+		"<img src=\"b.png\" srcset=\"b-2x.png 2x\" class=\"thumb\" sizes=\"10vw\"/>"+
+		"<img src=\"c.png\" class=\"thumb\"/>"+
+		"<img src=\"d.png\" srcset=\"d-2x.png 2x\" sizes=\"(max-width: 600px) 100vw, d.png\"/>"+
+		"</body></html>")
+}
+
+func TestFigurify(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<p> <img src=\"a.png\" alt=\"A cat\"> </p>" +
+		"<div><img src=\"b.png\" title=\"Chart\" alt=\"bars\"></div>" +
+		"<p>Inline <img src=\"c.png\" alt=\"icon\"> image</p>" +
+		"<figure><img src=\"d.png\" alt=\"done\"></figure>" +
+		"<div><img src=\"e.png\"></div>" +
+		"</body></html>")
+	tf := New(tree)
+	expected := "<html><head></head><body>" +
+		"<figure><img src=\"a.png\" alt=\"A cat\"/><figcaption>A cat</figcaption></figure>" +
+		"<div><figure><img src=\"b.png\" title=\"Chart\" alt=\"bars\"/><figcaption>Chart</figcaption></figure></div>" +
+		"<p>Inline <img src=\"c.png\" alt=\"icon\"/> image</p>" +
+		"<figure><img src=\"d.png\" alt=\"done\"/></figure>" +
+		"<div><figure><img src=\"e.png\"/></figure></div>" +
+		"</body></html>"
+	if err := tf.Figurify(""); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, tf.String(), expected)
+	if err := tf.Figurify(""); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, tf.String(), expected)
+
+	body := tf.Body()
+	fig := body.FirstChild
+	img := fig.FirstChild
+	if fig.Parent != body || img.Parent != fig || img.NextSibling.Parent != fig {
+		t.Errorf("Expected the figure's parent pointers to be set")
+	}
+
+	tree, _ = h5.NewFromString("<html><body>" +
+		"<p class=\"x\">Inline <img class=\"hero\" src=\"c.png\" alt=\"icon\"> image</p>" +
+		"<p><img class=\"hero\" src=\"d.png\"> trailing</p>" +
+		"<p><a href=\"/\"><img class=\"hero\" src=\"e.png\"></a></p>" +
+		"<p id=\"x\" class=\"y\">a <img class=\"hero\" src=\"f.png\"> b</p>" +
+		"<p id=\"z\"><img class=\"hero\" src=\"g.png\"> c</p>" +
+		"</body></html>")
+	tf = New(tree)
+	if err := tf.Figurify("img.hero"); err != nil {
+		t.Fatal(err)
+	}
+	expected = "<html><head></head><body>" +
+		"<p class=\"x\">Inline </p><figure><img class=\"hero\" src=\"c.png\" alt=\"icon\"/>" +
+		"<figcaption>icon</figcaption></figure><p class=\"x\"> image</p>" +
+		"<figure><img class=\"hero\" src=\"d.png\"/></figure><p> trailing</p>" +
+		"<p><a href=\"/\"><img class=\"hero\" src=\"e.png\"/></a></p>" +
+		"<p id=\"x\" class=\"y\">a </p><figure><img class=\"hero\" src=\"f.png\"/></figure><p class=\"y\"> b</p>" +
+		"<figure><img class=\"hero\" src=\"g.png\"/></figure><p id=\"z\"> c</p>" +
+		"</body></html>"
+	assertEqual(t, tf.String(), expected)
+	// The output is valid so parsing it again doesn't move anything.
+	reparsed, _ := h5.NewFromString(tf.String())
+	assertEqual(t, reparsed.String(), expected)
+}
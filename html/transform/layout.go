@@ -0,0 +1,139 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"golang.org/x/net/html"
+
+	"code.google.com/p/go-html-transform/h5"
+)
+
+// onlyElementChild returns true if n is the only element child of its parent
+// and its siblings are all whitespace.
+func onlyElementChild(n *html.Node) bool {
+	if n.Parent == nil {
+		return false
+	}
+	for c := n.Parent.FirstChild; c != nil; c = c.NextSibling {
+		if c != n && !isWhitespace(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// WrapTablesResponsive wraps every table in a div with the given class as
+// used by css frameworks to make tables scroll on small screens. Tables that
+// are already the only child of such a div are left alone so it is safe to
+// run more than once.
+func (t *Transformer) WrapTablesResponsive(class string) {
+	wrapper := h5.Div("", []string{class})
+	t.Apply(If(func(n *html.Node) bool {
+		p := n.Parent
+		return p != nil && isElement(p, "div") && HasClass(class)(p) &&
+			onlyElementChild(n)
+	}, nil, Wrap(wrapper)), "table")
+}
+
+// inlineElements are the elements that flow with text rather than starting
+// a new block.
+var inlineElements = map[string]bool{
+	"a": true, "abbr": true, "b": true, "bdi": true, "bdo": true, "br": true,
+	"button": true, "cite": true, "code": true, "data": true, "del": true,
+	"dfn": true, "em": true, "i": true, "img": true, "input": true,
+	"ins": true, "kbd": true, "label": true, "mark": true, "q": true,
+	"s": true, "samp": true, "select": true, "small": true, "span": true,
+	"strong": true, "sub": true, "sup": true, "textarea": true, "time": true,
+	"u": true, "var": true, "wbr": true,
+}
+
+// isInline returns true for text, comments and inline elements.
+func isInline(n *html.Node) bool {
+	switch n.Type {
+	case html.TextNode, html.CommentNode:
+		return true
+	case html.ElementNode:
+		return inlineElements[h5.Data(n)]
+	}
+	return false
+}
+
+// wrapRun moves the nodes of a run into a new wrapper element leaving the
+// whitespace at either end of the run outside of it.
+func wrapRun(run []*html.Node, wrapper string) {
+	for len(run) > 0 && isWhitespace(run[0]) {
+		run = run[1:]
+	}
+	for len(run) > 0 && isWhitespace(run[len(run)-1]) {
+		run = run[:len(run)-1]
+	}
+	if len(run) == 0 {
+		return
+	}
+	p := run[0].Parent
+	w := h5.Element(wrapper, nil)
+	p.InsertBefore(w, run[0])
+	for _, n := range run {
+		p.RemoveChild(n)
+		w.AppendChild(n)
+	}
+}
+
+// GroupInline creates a TransformFunc that wraps each run of adjacent text
+// and inline children of the node it operates on in a new wrapper element,
+// turning loose content into blocks such as paragraphs. Block children
+// split the runs and are left as they are. Whitespace only text at the
+// start or end of a run stays outside the wrapper and runs that are only
+// whitespace aren't wrapped.
+//
+//	<div>a <em>b</em><div>c</div>d</div> => <div><p>a <em>b</em></p><div>c</div><p>d</p></div>
+func GroupInline(wrapper string) TransformFunc {
+	return func(n *html.Node) {
+		var run []*html.Node
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if isInline(c) {
+				run = append(run, c)
+				continue
+			}
+			wrapRun(run, wrapper)
+			run = nil
+		}
+		wrapRun(run, wrapper)
+	}
+}
+
+// sameElement returns true if a and b have the same tag and attributes
+// regardless of their children.
+func sameElement(a, b *html.Node) bool {
+	shallow := func(n *html.Node) *html.Node {
+		return &html.Node{Type: n.Type, Data: h5.Data(n), Namespace: n.Namespace, Attr: n.Attr}
+	}
+	return a.Type == html.ElementNode && h5.Equal(shallow(a), shallow(b))
+}
+
+// WrapBody moves the children of the document's <body> into a copy of
+// container, after any children it already has, and makes the copy the only
+// child of the body. It does nothing if the body's only element is already
+// an element with the same tag and attributes as container so it is safe to
+// run more than once.
+//
+//	t.WrapBody(h5.Div("", []string{"page"}))
+func (t *Transformer) WrapBody(container *html.Node) {
+	body := t.Body()
+	if body == nil {
+		return
+	}
+	for c := body.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && onlyElementChild(c) && sameElement(c, container) {
+			return
+		}
+	}
+	w := h5.CloneNode(container)
+	for c := body.FirstChild; c != nil; c = body.FirstChild {
+		body.RemoveChild(c)
+		w.AppendChild(c)
+	}
+	body.AppendChild(w)
+}
@@ -0,0 +1,58 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"testing"
+
+	"code.google.com/p/go-html-transform/h5"
+)
+
+func TestWrapTablesResponsive(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<table><tr><td>a</td></tr></table>" +
+		"<div class=\"table-responsive\"><table></table></div>" +
+		"</body></html>")
+	tf := New(tree)
+	expected := "<html><head></head><body>" +
+		"<div class=\"table-responsive\"><table><tbody><tr><td>a</td></tr></tbody></table></div>" +
+		"<div class=\"table-responsive\"><table></table></div>" +
+		"</body></html>"
+	tf.WrapTablesResponsive("table-responsive")
+	assertEqual(t, tf.String(), expected)
+	tf.WrapTablesResponsive("table-responsive")
+	assertEqual(t, tf.String(), expected)
+}
+
+func TestGroupInline(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body><div>" +
+		"\n  Some <em>loose</em> text\n  <div>block</div>\n  " +
+		"<a href=\"/\">more</a><br>tail\n" +
+		"<ul><li>x</li></ul> " +
+		"</div></body></html>")
+	tf := New(tree)
+	tf.Apply(GroupInline("p"), "body > div")
+	assertEqual(t, tf.String(), "<html><head></head><body><div>"+
+		"<p>\n  Some <em>loose</em> text\n  </p><div>block</div>\n  "+
+		"<p><a href=\"/\">more</a><br/>tail\n</p>"+
+		"<ul><li>x</li></ul> "+
+		"</div></body></html>")
+}
+
+func TestWrapBody(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body><h1>a</h1>\n<p>b</p></body></html>")
+	tf := New(tree)
+	container := h5.Div("", []string{"page"}, h5.Element("header", nil))
+	tf.WrapBody(container)
+	expected := "<html><head></head><body><div class=\"page\"><header></header>" +
+		"<h1>a</h1>\n<p>b</p></div></body></html>"
+	assertEqual(t, tf.String(), expected)
+	tf.WrapBody(container)
+	assertEqual(t, tf.String(), expected)
+	assertEqual(t, h5.NewTree(container).String(), "<div class=\"page\"><header></header></div>")
+	tf.WrapBody(h5.Element("main", nil))
+	assertEqual(t, tf.String(), "<html><head></head><body><main><div class=\"page\"><header></header>"+
+		"<h1>a</h1>\n<p>b</p></div></main></body></html>")
+}
@@ -0,0 +1,129 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// referrerPolicies is the set of valid referrerpolicy values.
+var referrerPolicies = map[string]bool{
+	"":                                true,
+	"no-referrer":                     true,
+	"no-referrer-when-downgrade":      true,
+	"origin":                          true,
+	"origin-when-cross-origin":        true,
+	"same-origin":                     true,
+	"strict-origin":                   true,
+	"strict-origin-when-cross-origin": true,
+	"unsafe-url":                      true,
+}
+
+// SetReferrerPolicy sets the referrerpolicy attribute on every element with
+// one of the given tags, replacing any existing value. If no tags are given
+// it defaults to a, img and iframe elements. It returns an error if policy is
+// not a known referrer policy.
+//
+//	t.SetReferrerPolicy("no-referrer")
+func (t *Transformer) SetReferrerPolicy(policy string, tags ...string) error {
+	if !referrerPolicies[policy] {
+		return fmt.Errorf("Unknown referrer policy %q", policy)
+	}
+	if len(tags) == 0 {
+		tags = []string{"a", "img", "iframe"}
+	}
+	for _, tag := range tags {
+		if err := t.Apply(ModifyAttrib("referrerpolicy", policy), tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AnnotateExternalLinks creates a TransformFunc that appends a copy of icon
+// to an <a> whose href points to an origin other than host, so readers can
+// tell which links leave the site. Relative links, links to host and
+// anchors without an href are left alone.
+//
+//	icon := h5.Element("span", []html.Attribute{{Key: "class", Val: "external"}})
+//	t.Apply(AnnotateExternalLinks("www.example.com", icon), "a")
+func AnnotateExternalLinks(host string, icon *html.Node) TransformFunc {
+	return func(n *html.Node) {
+		if !isElement(n, "a") {
+			return
+		}
+		href, ok := getAttr(n, "href")
+		if !ok {
+			return
+		}
+		if _, external := origin(href, host); external {
+			AppendChildren(icon)(n)
+		}
+	}
+}
+
+// downloadName returns the last segment of the path of href or "" if it
+// has none.
+func downloadName(href string) string {
+	u, err := url.Parse(href)
+	if err != nil || u.Path == "" || strings.HasSuffix(u.Path, "/") {
+		return ""
+	}
+	return path.Base(u.Path)
+}
+
+// MarkDownloadLinks adds the download attribute to every <a> whose href
+// ends with one of the file extensions, like "pdf" or ".zip", so browsers
+// save the file rather than navigate to it. If setFilename is true the
+// attribute is set to the last segment of the url's path to name the saved
+// file. Anchors that already have a download attribute are left alone. The
+// extensions are matched case sensitively against the whole href.
+//
+//	t.MarkDownloadLinks(true, "pdf", "zip")
+func (t *Transformer) MarkDownloadLinks(setFilename bool, exts ...string) error {
+	mark := func(n *html.Node) {
+		name := ""
+		if setFilename {
+			href, _ := getAttr(n, "href")
+			name = downloadName(href)
+		}
+		ModifyAttrib("download", name)(n)
+	}
+	for _, ext := range exts {
+		ext = "." + strings.TrimPrefix(ext, ".")
+		if err := t.Apply(mark, "a[href$=\""+ext+"\"]:not([download])"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NormalizeRel creates a TransformFunc that puts the rel attribute of the
+// node it operates on in a canonical form with its link types lowercased,
+// deduplicated and sorted, so equivalent links render the same. A rel left
+// empty is removed and nodes without a rel are left alone.
+//
+//	rel="noopener  NOFOLLOW noopener" => rel="nofollow noopener"
+func NormalizeRel() TransformFunc {
+	return func(n *html.Node) {
+		val, ok := getAttr(n, "rel")
+		if !ok {
+			return
+		}
+		list := uniqueTokens(strings.Fields(strings.ToLower(val)))
+		if len(list) == 0 {
+			removeAttr(n, "rel")
+			return
+		}
+		sort.Strings(list)
+		ModifyAttrib("rel", strings.Join(list, " "))(n)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"testing"
+
+	"golang.org/x/net/html"
+
+	"code.google.com/p/go-html-transform/h5"
+)
+
+func TestSetReferrerPolicy(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<a href=\"/\">a</a><img src=\"i.png\">" +
+		"<iframe src=\"f.html\" referrerpolicy=\"unsafe-url\"></iframe>" +
+		"<p>p</p></body></html>")
+	tf := New(tree)
+	if err := tf.SetReferrerPolicy("no-referrer"); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<a href=\"/\" referrerpolicy=\"no-referrer\">a</a>"+
+		"<img src=\"i.png\" referrerpolicy=\"no-referrer\"/>"+
+		"<iframe src=\"f.html\" referrerpolicy=\"no-referrer\"></iframe>"+
+		"<p>p</p></body></html>")
+	if err := tf.SetReferrerPolicy("same-origin", "p"); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<a href=\"/\" referrerpolicy=\"no-referrer\">a</a>"+
+		"<img src=\"i.png\" referrerpolicy=\"no-referrer\"/>"+
+		"<iframe src=\"f.html\" referrerpolicy=\"no-referrer\"></iframe>"+
+		"<p referrerpolicy=\"same-origin\">p</p></body></html>")
+	if err := tf.SetReferrerPolicy("nobody"); err == nil {
+		t.Error("Expected an error for an unknown policy")
+	}
+}
+
+func TestAnnotateExternalLinks(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<a href=\"https://other.example/\">one</a>" +
+		"<a href=\"https://www.example.com/about\">about</a>" +
+		"<a href=\"/local\">local</a>" +
+		"<a name=\"anchor\">anchor</a>" +
+		"<a href=\"//cdn.example/file\">two</a>" +
+		"</body></html>")
+	tf := New(tree)
+	icon := h5.Element("span", []html.Attribute{{Key: "class", Val: "external"}})
+	tf.Apply(AnnotateExternalLinks("www.example.com", icon), "a")
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<a href=\"https://other.example/\">one<span class=\"external\"></span></a>"+
+		"<a href=\"https://www.example.com/about\">about</a>"+
+		"<a href=\"/local\">local</a>"+
+		"<a name=\"anchor\">anchor</a>"+
+		"<a href=\"//cdn.example/file\">two<span class=\"external\"></span></a>"+
+		"</body></html>")
+	body := tf.Body()
+	first, last := body.FirstChild.LastChild, body.LastChild.LastChild
+	if first == last || first == icon || last == icon {
+		t.Errorf("Expected each link to get its own copy of the icon")
+	}
+}
+
+func TestMarkDownloadLinks(t *testing.T) {
+	doc := "<html><body>" +
+		"<a href=\"/files/report%202024.pdf\">report</a>" +
+		"<a href=\"https://cdn.example/pkg.zip\">zip</a>" +
+		"<a download=\"custom.pdf\" href=\"/files/kept.pdf\">kept</a>" +
+		"<a href=\"/about\">about</a>" +
+		"</body></html>"
+	tree, _ := h5.NewFromString(doc)
+	tf := New(tree)
+	if err := tf.MarkDownloadLinks(false, "pdf", ".zip"); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<a href=\"/files/report%202024.pdf\" download=\"\">report</a>"+
+		"<a href=\"https://cdn.example/pkg.zip\" download=\"\">zip</a>"+
+		"<a download=\"custom.pdf\" href=\"/files/kept.pdf\">kept</a>"+
+		"<a href=\"/about\">about</a>"+
+		"</body></html>")
+
+	tree, _ = h5.NewFromString(doc)
+	tf = New(tree)
+	if err := tf.MarkDownloadLinks(true, "pdf"); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<a href=\"/files/report%202024.pdf\" download=\"report 2024.pdf\">report</a>"+
+		"<a href=\"https://cdn.example/pkg.zip\">zip</a>"+
+		"<a download=\"custom.pdf\" href=\"/files/kept.pdf\">kept</a>"+
+		"<a href=\"/about\">about</a>"+
+		"</body></html>")
+}
+
+func TestNormalizeRel(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><head>" +
+		"<link rel=\" Stylesheet \" href=\"a.css\">" +
+		"</head><body>" +
+		"<a href=\"/x\" rel=\"noopener  NOFOLLOW noopener\">x</a>" +
+		"<a href=\"/y\" rel=\" \">y</a>" +
+		"<a href=\"/z\">z</a>" +
+		"</body></html>")
+	tf := New(tree)
+	tf.Apply(NormalizeRel(), "[rel]")
+	assertEqual(t, tf.String(), "<html><head>"+
+		"<link rel=\"stylesheet\" href=\"a.css\"/>"+
+		"</head><body>"+
+		"<a href=\"/x\" rel=\"nofollow noopener\">x</a>"+
+		"<a href=\"/y\">y</a>"+
+		"<a href=\"/z\">z</a>"+
+		"</body></html>")
+	node := h5.Anchor("/z", "z")
+	NormalizeRel()(node)
+	if len(node.Attr) != 1 {
+		t.Errorf("Expected an anchor without rel to be left alone got %v", node.Attr)
+	}
+}
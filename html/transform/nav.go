@@ -0,0 +1,48 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"golang.org/x/net/html"
+
+	"code.google.com/p/go-html-transform/h5"
+)
+
+// Breadcrumb is one step of the path rendered by BuildBreadcrumbs.
+type Breadcrumb struct {
+	Label, URL string
+}
+
+// BuildBreadcrumbs appends a breadcrumb trail for segs to container and
+// returns the <nav> it added.
+//
+//	<nav aria-label="Breadcrumb"><ol>
+//	  <li><a href="/">Home</a></li>
+//	  <li><a href="/docs/" aria-current="page">Docs</a></li>
+//	</ol></nav>
+//
+// The last segment is the current page and is marked with aria-current. If
+// currentAsText is true it is rendered as a <span> instead of a link.
+// Segments without a URL are always rendered as text.
+func BuildBreadcrumbs(segs []Breadcrumb, container *html.Node, currentAsText bool) *html.Node {
+	ol := h5.Element("ol", nil)
+	for i, seg := range segs {
+		current := i == len(segs)-1
+		var item *html.Node
+		if seg.URL == "" || (current && currentAsText) {
+			item = h5.Element("span", nil, h5.Text(seg.Label))
+		} else {
+			item = h5.Anchor(seg.URL, seg.Label)
+		}
+		if current {
+			ModifyAttrib("aria-current", "page")(item)
+		}
+		ol.AppendChild(h5.Element("li", nil, item))
+	}
+	nav := h5.Element("nav",
+		[]html.Attribute{{Key: "aria-label", Val: "Breadcrumb"}}, ol)
+	container.AppendChild(nav)
+	return nav
+}
@@ -0,0 +1,41 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"testing"
+
+	"golang.org/x/net/html"
+
+	"code.google.com/p/go-html-transform/h5"
+)
+
+func TestBuildBreadcrumbs(t *testing.T) {
+	segs := []Breadcrumb{
+		{"Home", "/"},
+		{"Docs", "/docs/"},
+		{"Selectors", "/docs/selectors/"},
+	}
+	header := h5.Element("header", nil)
+	BuildBreadcrumbs(segs, header, false)
+	assertEqual(t, h5.RenderNodesToString([]*html.Node{header}),
+		"<header><nav aria-label=\"Breadcrumb\"><ol>"+
+			"<li><a href=\"/\">Home</a></li>"+
+			"<li><a href=\"/docs/\">Docs</a></li>"+
+			"<li><a href=\"/docs/selectors/\" aria-current=\"page\">Selectors</a></li>"+
+			"</ol></nav></header>")
+
+	header = h5.Element("header", nil)
+	nav := BuildBreadcrumbs(segs, header, true)
+	if nav.Parent != header {
+		t.Errorf("Expected the nav to be appended to the container")
+	}
+	assertEqual(t, h5.RenderNodesToString([]*html.Node{header}),
+		"<header><nav aria-label=\"Breadcrumb\"><ol>"+
+			"<li><a href=\"/\">Home</a></li>"+
+			"<li><a href=\"/docs/\">Docs</a></li>"+
+			"<li><span aria-current=\"page\">Selectors</span></li>"+
+			"</ol></nav></header>")
+}
@@ -0,0 +1,103 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"golang.org/x/net/html"
+)
+
+// If creates a TransformFunc that applies then to the nodes pred returns
+// true for and els to the rest. Either TransformFunc may be nil.
+//
+//	If(And(HasClass("btn"), Not(HasAttrib("disabled"))), AddClass("active"), nil)
+func If(pred func(*html.Node) bool, then, els TransformFunc) TransformFunc {
+	return func(n *html.Node) {
+		if pred(n) {
+			if then != nil {
+				then(n)
+			}
+		} else if els != nil {
+			els(n)
+		}
+	}
+}
+
+// And returns a predicate that is true if all of preds are true. It stops
+// evaluating at the first false predicate.
+func And(preds ...func(*html.Node) bool) func(*html.Node) bool {
+	return func(n *html.Node) bool {
+		for _, p := range preds {
+			if !p(n) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or returns a predicate that is true if any of preds are true. It stops
+// evaluating at the first true predicate.
+func Or(preds ...func(*html.Node) bool) func(*html.Node) bool {
+	return func(n *html.Node) bool {
+		for _, p := range preds {
+			if p(n) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not returns a predicate that negates pred.
+func Not(pred func(*html.Node) bool) func(*html.Node) bool {
+	return func(n *html.Node) bool {
+		return !pred(n)
+	}
+}
+
+// HasAttrib returns a predicate that is true for nodes with the attribute.
+func HasAttrib(key string) func(*html.Node) bool {
+	return func(n *html.Node) bool {
+		_, ok := getAttr(n, key)
+		return ok
+	}
+}
+
+// HasClass returns a predicate that is true for nodes with the class.
+func HasClass(class string) func(*html.Node) bool {
+	return func(n *html.Node) bool {
+		classes, _ := getAttr(n, "class")
+		return attrContains(class, classes)
+	}
+}
+
+// depth returns the number of element ancestors of n.
+func depth(n *html.Node) int {
+	d := 0
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode {
+			d++
+		}
+	}
+	return d
+}
+
+// DepthAtLeast returns a predicate that is true for nodes with at least d
+// element ancestors. The <html> element has a depth of 0 and <body> of 1.
+//
+//	If(DepthAtLeast(6), Rename("div"), nil)
+func DepthAtLeast(d int) func(*html.Node) bool {
+	return func(n *html.Node) bool {
+		return depth(n) >= d
+	}
+}
+
+// DepthAtMost returns a predicate that is true for nodes with at most d
+// element ancestors.
+func DepthAtMost(d int) func(*html.Node) bool {
+	return func(n *html.Node) bool {
+		return depth(n) <= d
+	}
+}
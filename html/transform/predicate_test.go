@@ -0,0 +1,74 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"testing"
+
+	"golang.org/x/net/html"
+
+	"code.google.com/p/go-html-transform/h5"
+)
+
+// counting returns a predicate that always returns val and counts its calls.
+func counting(val bool, calls *int) func(*html.Node) bool {
+	return func(*html.Node) bool {
+		*calls++
+		return val
+	}
+}
+
+func TestAndShortCircuits(t *testing.T) {
+	var calls int
+	n := h5.Anchor("", "")
+	assertEqual(t, And(counting(true, &calls), counting(true, &calls))(n), true)
+	assertEqual(t, calls, 2)
+	calls = 0
+	assertEqual(t, And(counting(false, &calls), counting(true, &calls))(n), false)
+	assertEqual(t, calls, 1)
+	assertEqual(t, And()(n), true)
+}
+
+func TestOrShortCircuits(t *testing.T) {
+	var calls int
+	n := h5.Anchor("", "")
+	assertEqual(t, Or(counting(false, &calls), counting(false, &calls))(n), false)
+	assertEqual(t, calls, 2)
+	calls = 0
+	assertEqual(t, Or(counting(true, &calls), counting(false, &calls))(n), true)
+	assertEqual(t, calls, 1)
+	assertEqual(t, Or()(n), false)
+}
+
+func TestIf(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<a class=\"btn\">a</a>" +
+		"<a class=\"btn\" disabled>b</a>" +
+		"<a>c</a></body></html>")
+	tf := New(tree)
+	tf.Apply(If(And(HasClass("btn"), Not(HasAttrib("disabled"))),
+		AddClass("active"), ModifyAttrib("title", "off")), "a")
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<a class=\"btn active\">a</a>"+
+		"<a class=\"btn\" disabled=\"\" title=\"off\">b</a>"+
+		"<a title=\"off\">c</a></body></html>")
+}
+
+func TestDepth(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body><ul><li>1<ul><li>2<ul><li id=\"deep\">3" +
+		"</li></ul></li></ul></li></ul></body></html>")
+	tf := New(tree)
+	var deep *html.Node
+	tf.Apply(func(n *html.Node) { deep = n }, "#deep")
+	// html > body > ul > li > ul > li > ul > li
+	assertEqual(t, DepthAtLeast(7)(deep), true)
+	assertEqual(t, DepthAtLeast(8)(deep), false)
+	assertEqual(t, DepthAtMost(7)(deep), true)
+	assertEqual(t, DepthAtMost(6)(deep), false)
+	tf.Apply(If(DepthAtLeast(5), AddClass("nested"), nil), "li")
+	assertEqual(t, tf.String(), "<html><head></head><body><ul><li>1<ul>"+
+		"<li class=\"nested\">2<ul><li id=\"deep\" class=\"nested\">3"+
+		"</li></ul></li></ul></li></ul></body></html>")
+}
@@ -0,0 +1,318 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"code.google.com/p/go-html-transform/h5"
+)
+
+// isStylesheet returns true if n is a <link> element with a stylesheet rel.
+func isStylesheet(n *html.Node) bool {
+	if !isElement(n, "link") {
+		return false
+	}
+	rel, _ := getAttr(n, "rel")
+	return attrContains("stylesheet", rel)
+}
+
+// isExternalScript returns true if n is a <script> element with a src.
+func isExternalScript(n *html.Node) bool {
+	if !isElement(n, "script") {
+		return false
+	}
+	_, ok := getAttr(n, "src")
+	return ok
+}
+
+// InlineResources creates a TransformFunc that replaces a
+// <link rel="stylesheet"> with an inline <style> element and a
+// <script src="..."> with an inline <script> element. The contents are
+// fetched using resolve and are only inlined if they are at most maxSize
+// bytes long. Resources that are too large, fail to resolve, or that can't
+// be safely embedded are left as references, as are scripts with defer or
+// async which would run too early once inlined. Nodes that are neither
+// stylesheets nor external scripts are left alone.
+//
+//	t.Apply(InlineResources(fetch, 4096), "link")
+//	t.Apply(InlineResources(fetch, 4096), "script")
+func InlineResources(resolve func(url string) ([]byte, error), maxSize int) TransformFunc {
+	return func(n *html.Node) {
+		var tag, key string
+		var keep []string
+		switch {
+		case isStylesheet(n):
+			tag, key, keep = "style", "href", []string{"media", "nonce"}
+		case isExternalScript(n):
+			// defer and async have no effect on inline scripts so
+			// inlining would run them before the document is parsed.
+			if HasAttrib("defer")(n) || HasAttrib("async")(n) {
+				return
+			}
+			tag, key, keep = "script", "src", []string{"type", "nonce"}
+		default:
+			return
+		}
+		url, _ := getAttr(n, key)
+		content, err := resolve(url)
+		if err != nil || len(content) > maxSize {
+			return
+		}
+		// Contents containing the closing tag would end the element early.
+		if bytes.Contains(bytes.ToLower(content), []byte("</"+tag)) {
+			return
+		}
+		var attrs []html.Attribute
+		for _, k := range keep {
+			if v, ok := getAttr(n, k); ok {
+				attrs = append(attrs, html.Attribute{Key: k, Val: v})
+			}
+		}
+		Replace(h5.Element(tag, attrs, h5.Text(string(content))))(n)
+	}
+}
+
+// parseDataURI decodes a data: URI returning its media type, without any
+// parameters, and its contents. ok is false if uri isn't a data: URI.
+func parseDataURI(uri string) (mime string, data []byte, ok bool, err error) {
+	if len(uri) < 5 || !strings.EqualFold(uri[:5], "data:") {
+		return "", nil, false, nil
+	}
+	comma := strings.Index(uri, ",")
+	if comma < 0 {
+		return "", nil, true, fmt.Errorf("Malformed data URI %q", uri)
+	}
+	params := strings.Split(uri[5:comma], ";")
+	mime = strings.ToLower(strings.TrimSpace(params[0]))
+	if mime == "" {
+		mime = "text/plain"
+	}
+	payload, err := url.PathUnescape(uri[comma+1:])
+	if err != nil {
+		return "", nil, true, err
+	}
+	if strings.EqualFold(params[len(params)-1], "base64") {
+		data, err = base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			return "", nil, true, err
+		}
+		return mime, data, true, nil
+	}
+	return mime, []byte(payload), true, nil
+}
+
+// ExternalizeDataURIs is the reverse of inlining. It decodes the data: URI
+// in the src of every img element and passes its media type and contents to
+// store, replacing the src with the url store returns. Other srcs are left
+// alone. It stops and returns the error if a data: URI can't be decoded or
+// store fails.
+//
+//	err := t.ExternalizeDataURIs(func(mime string, data []byte) (string, error) {
+//		return save(mime, data)
+//	})
+func (t *Transformer) ExternalizeDataURIs(store func(mime string, data []byte) (url string, err error)) error {
+	var err error
+	h5.WalkNodes(t.root(), func(n *html.Node) {
+		if err != nil || !isElement(n, "img") {
+			return
+		}
+		src, _ := getAttr(n, "src")
+		mime, data, ok, perr := parseDataURI(strings.TrimSpace(src))
+		if !ok {
+			return
+		}
+		if perr != nil {
+			err = perr
+			return
+		}
+		var u string
+		if u, err = store(mime, data); err == nil {
+			ModifyAttrib("src", u)(n)
+		}
+	})
+	return err
+}
+
+// AddIntegrity creates a TransformFunc that adds subresource integrity to
+// a <script src="..."> or <link rel="stylesheet">. It looks up the hash
+// for the url with resolve and sets integrity and, unless it is already
+// set, crossorigin="anonymous". Elements that already have an integrity
+// attribute or whose url resolve doesn't know are left alone.
+//
+//	t.Apply(AddIntegrity(hashes), "script")
+//	t.Apply(AddIntegrity(hashes), "link")
+func AddIntegrity(resolve func(url string) (hash string, ok bool)) TransformFunc {
+	return func(n *html.Node) {
+		var key string
+		switch {
+		case isStylesheet(n):
+			key = "href"
+		case isExternalScript(n):
+			key = "src"
+		default:
+			return
+		}
+		if _, ok := getAttr(n, "integrity"); ok {
+			return
+		}
+		url, _ := getAttr(n, key)
+		hash, ok := resolve(url)
+		if !ok {
+			return
+		}
+		ModifyAttrib("integrity", hash)(n)
+		setDefaultAttrib(n, "crossorigin", "anonymous")
+	}
+}
+
+// MarkStylesheetMedia creates a TransformFunc that sets the media
+// attribute of a <link rel="stylesheet"> whose href pred returns true for.
+// Other links and elements are left alone.
+//
+//	t.Apply(MarkStylesheetMedia(func(href string) bool {
+//		return strings.HasSuffix(href, "print.css")
+//	}, "print"), "link")
+func MarkStylesheetMedia(pred func(href string) bool, media string) TransformFunc {
+	return func(n *html.Node) {
+		if !isStylesheet(n) {
+			return
+		}
+		if href, _ := getAttr(n, "href"); pred(href) {
+			ModifyAttrib("media", media)(n)
+		}
+	}
+}
+
+// corsPreloads are the kinds of preload the browser always fetches in cors
+// mode so the preload is only reused if it has crossorigin too.
+var corsPreloads = map[string]bool{
+	"font":  true,
+	"fetch": true,
+}
+
+// SetPreloadCrossorigin adds crossorigin="anonymous" to every
+// <link rel="preload"> in the document for a font or fetch, which browsers
+// always request with cors. Preloads that already have a crossorigin
+// attribute and other kinds of preload are left alone.
+func (t *Transformer) SetPreloadCrossorigin() {
+	t.Apply(func(n *html.Node) {
+		rel, _ := getAttr(n, "rel")
+		as, _ := getAttr(n, "as")
+		if attrContains("preload", strings.ToLower(rel)) &&
+			corsPreloads[strings.ToLower(strings.TrimSpace(as))] {
+			setDefaultAttrib(n, "crossorigin", "anonymous")
+		}
+	}, "link[rel][as]")
+}
+
+// hintAttribs are the attributes that hold the url an element fetches.
+var hintAttribs = map[string]string{
+	"img":    "src",
+	"script": "src",
+	"link":   "href",
+}
+
+// fetchedRels are the link types whose href the browser fetches. Links with
+// only other types, like canonical or alternate, just point at a url.
+var fetchedRels = map[string]bool{
+	"stylesheet":       true,
+	"preload":          true,
+	"modulepreload":    true,
+	"icon":             true,
+	"apple-touch-icon": true,
+	"manifest":         true,
+}
+
+// isFetchedLink returns true if the browser fetches the href of the <link>
+// n.
+func isFetchedLink(n *html.Node) bool {
+	rel, _ := getAttr(n, "rel")
+	for _, r := range strings.Fields(strings.ToLower(rel)) {
+		if fetchedRels[r] {
+			return true
+		}
+	}
+	return false
+}
+
+// origin returns the origin of an absolute or protocol relative url and
+// false for relative urls and urls on host.
+func origin(raw, host string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" || strings.EqualFold(u.Host, host) {
+		return "", false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "":
+		return "//" + strings.ToLower(u.Host), true
+	case "http", "https":
+		return strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Host), true
+	}
+	return "", false
+}
+
+// AddResourceHints adds a <link rel="preconnect"> to the document's <head>
+// for every origin other than host that an img, script or link in the
+// document fetches from, like a stylesheet or icon, so the browser can open the connections early. If
+// dnsPrefetch is true a <link rel="dns-prefetch"> is added as well for
+// browsers that don't support preconnect. The hints go before the first
+// link, script or style in the head and origins that already have a
+// preconnect are skipped. It returns the origins it added hints for in
+// document order.
+func (t *Transformer) AddResourceHints(host string, dnsPrefetch bool) []string {
+	head := t.Head()
+	if head == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	t.Apply(func(n *html.Node) {
+		rel, _ := getAttr(n, "rel")
+		href, _ := getAttr(n, "href")
+		if o, ok := origin(href, host); ok &&
+			attrContains("preconnect", strings.ToLower(rel)) {
+			seen[o] = true
+		}
+	}, "link[rel]")
+	var origins []string
+	h5.WalkNodes(t.Doc(), func(n *html.Node) {
+		if n.Type != html.ElementNode {
+			return
+		}
+		key, ok := hintAttribs[h5.Data(n)]
+		if !ok || isElement(n, "link") && !isFetchedLink(n) {
+			return
+		}
+		val, _ := getAttr(n, key)
+		o, ok := origin(val, host)
+		if !ok || seen[o] {
+			return
+		}
+		seen[o] = true
+		origins = append(origins, o)
+	})
+	var before *html.Node
+	for c := head.FirstChild; c != nil; c = c.NextSibling {
+		if isElement(c, "link") || isElement(c, "script") || isElement(c, "style") {
+			before = c
+			break
+		}
+	}
+	for _, o := range origins {
+		head.InsertBefore(h5.Element("link", []html.Attribute{
+			{Key: "rel", Val: "preconnect"}, {Key: "href", Val: o}}), before)
+		if dnsPrefetch {
+			head.InsertBefore(h5.Element("link", []html.Attribute{
+				{Key: "rel", Val: "dns-prefetch"}, {Key: "href", Val: o}}), before)
+		}
+	}
+	return origins
+}
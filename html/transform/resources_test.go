@@ -0,0 +1,197 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+
+	"code.google.com/p/go-html-transform/h5"
+)
+
+func stubResolver(files map[string]string) func(string) ([]byte, error) {
+	return func(url string) ([]byte, error) {
+		if s, ok := files[url]; ok {
+			return []byte(s), nil
+		}
+		return nil, fmt.Errorf("not found: %s", url)
+	}
+}
+
+func TestInlineResources(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><head>" +
+		"<link rel=\"stylesheet\" href=\"small.css\" media=\"print\">" +
+		"<link rel=\"stylesheet\" href=\"big.css\">" +
+		"<link rel=\"icon\" href=\"small.css\">" +
+		"</head><body>" +
+		"<script src=\"small.js\"></script>" +
+		"<script src=\"missing.js\"></script>" +
+		"<script src=\"small.js\" defer></script>" +
+		"<script src=\"small.js\" async></script>" +
+		"</body></html>")
+	tf := New(tree)
+	f := InlineResources(stubResolver(map[string]string{
+		"small.css": "a{color:red}",
+		"big.css":   "body{margin:0;padding:0;border:none}",
+		"small.js":  "var a = 1 < 2;",
+	}), 16)
+	tf.Apply(f, "link")
+	tf.Apply(f, "script")
+	assertEqual(t, tf.String(), "<html><head>"+
+		"<style media=\"print\">a{color:red}</style>"+
+		"<link rel=\"stylesheet\" href=\"big.css\"/>"+
+		"<link rel=\"icon\" href=\"small.css\"/>"+
+		"</head><body>"+
+		"<script>var a = 1 < 2;</script>"+
+		"<script src=\"missing.js\"></script>"+
+		"<script src=\"small.js\" defer=\"\"></script>"+
+		"<script src=\"small.js\" async=\"\"></script>"+
+		"</body></html>")
+}
+
+func TestExternalizeDataURIs(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<img src=\"data:image/png;base64,aGVsbG8=\">" +
+		"<img src=\"logo.png\">" +
+		"<img src=\"data:,a%20b\">" +
+		"</body></html>")
+	tf := New(tree)
+	var stored []string
+	err := tf.ExternalizeDataURIs(func(mime string, data []byte) (string, error) {
+		stored = append(stored, mime+":"+string(data))
+		return fmt.Sprintf("/assets/%d", len(stored)), nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	assertEqual(t, strings.Join(stored, "|"), "image/png:hello|text/plain:a b")
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<img src=\"/assets/1\"/>"+
+		"<img src=\"logo.png\"/>"+
+		"<img src=\"/assets/2\"/>"+
+		"</body></html>")
+
+	tree, _ = h5.NewFromString("<html><body>" +
+		"<img src=\"data:image/gif;base64,R0lG\"></body></html>")
+	tf = New(tree)
+	err = tf.ExternalizeDataURIs(func(mime string, data []byte) (string, error) {
+		return "", fmt.Errorf("disk full")
+	})
+	if err == nil || err.Error() != "disk full" {
+		t.Errorf("Expected the store error got %v", err)
+	}
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<img src=\"data:image/gif;base64,R0lG\"/></body></html>")
+}
+
+func TestAddIntegrity(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><head>" +
+		"<link rel=\"stylesheet\" href=\"a.css\">" +
+		"<link rel=\"icon\" href=\"a.css\">" +
+		"</head><body>" +
+		"<script src=\"a.js\" crossorigin=\"use-credentials\"></script>" +
+		"<script src=\"b.js\" integrity=\"sha384-old\"></script>" +
+		"<script src=\"unknown.js\"></script>" +
+		"<script>inline()</script>" +
+		"</body></html>")
+	tf := New(tree)
+	hashes := map[string]string{
+		"a.css": "sha384-css",
+		"a.js":  "sha384-js",
+		"b.js":  "sha384-new",
+	}
+	f := AddIntegrity(func(url string) (string, bool) {
+		h, ok := hashes[url]
+		return h, ok
+	})
+	tf.Apply(f, "link")
+	tf.Apply(f, "script")
+	assertEqual(t, tf.String(), "<html><head>"+
+		"<link rel=\"stylesheet\" href=\"a.css\" integrity=\"sha384-css\" crossorigin=\"anonymous\"/>"+
+		"<link rel=\"icon\" href=\"a.css\"/>"+
+		"</head><body>"+
+		"<script src=\"a.js\" crossorigin=\"use-credentials\" integrity=\"sha384-js\"></script>"+
+		"<script src=\"b.js\" integrity=\"sha384-old\"></script>"+
+		"<script src=\"unknown.js\"></script>"+
+		"<script>inline()</script>"+
+		"</body></html>")
+}
+
+func TestMarkStylesheetMedia(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><head>" +
+		"<link rel=\"stylesheet\" href=\"/css/print.css\">" +
+		"<link rel=\"stylesheet\" href=\"/css/main.css\">" +
+		"<link rel=\"alternate\" href=\"/print.css\">" +
+		"</head><body></body></html>")
+	tf := New(tree)
+	tf.Apply(MarkStylesheetMedia(func(href string) bool {
+		return strings.HasSuffix(href, "print.css")
+	}, "print"), "link")
+	assertEqual(t, tf.String(), "<html><head>"+
+		"<link rel=\"stylesheet\" href=\"/css/print.css\" media=\"print\"/>"+
+		"<link rel=\"stylesheet\" href=\"/css/main.css\"/>"+
+		"<link rel=\"alternate\" href=\"/print.css\"/>"+
+		"</head><body></body></html>")
+}
+
+func TestSetPreloadCrossorigin(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><head>" +
+		"<link rel=\"preload\" as=\"font\" href=\"a.woff2\" type=\"font/woff2\">" +
+		"<link rel=\"Preload\" as=\"fetch\" href=\"/data.json\" crossorigin=\"use-credentials\">" +
+		"<link rel=\"preload\" as=\"image\" href=\"hero.png\">" +
+		"<link rel=\"stylesheet\" as=\"font\" href=\"a.css\">" +
+		"</head><body></body></html>")
+	tf := New(tree)
+	tf.SetPreloadCrossorigin()
+	assertEqual(t, tf.String(), "<html><head>"+
+		"<link rel=\"preload\" as=\"font\" href=\"a.woff2\" type=\"font/woff2\" crossorigin=\"anonymous\"/>"+
+		"<link rel=\"Preload\" as=\"fetch\" href=\"/data.json\" crossorigin=\"use-credentials\"/>"+
+		"<link rel=\"preload\" as=\"image\" href=\"hero.png\"/>"+
+		"<link rel=\"stylesheet\" as=\"font\" href=\"a.css\"/>"+
+		"</head><body></body></html>")
+}
+
+func TestAddResourceHints(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><head>" +
+		"<title>t</title>" +
+		"<link rel=\"preconnect\" href=\"https://known.example\">" +
+		"<link rel=\"stylesheet\" href=\"https://fonts.example/css?family=x\">" +
+		"<link rel=\"canonical\" href=\"https://other.example/page\">" +
+		"<link rel=\"alternate\" hreflang=\"fr\" href=\"https://fr.example/page\">" +
+		"</head><body>" +
+		"<img src=\"https://cdn.example/a.png\">" +
+		"<img src=\"https://cdn.example/b.png\">" +
+		"<img src=\"https://www.example.com/c.png\">" +
+		"<img src=\"/d.png\">" +
+		"<script src=\"https://known.example/app.js\"></script>" +
+		"</body></html>")
+	tf := New(tree)
+	origins := tf.AddResourceHints("www.example.com", false)
+	if !reflect.DeepEqual(origins, []string{"https://fonts.example", "https://cdn.example"}) {
+		t.Errorf("Unexpected origins %v", origins)
+	}
+	assertEqual(t, h5.RenderNodesToString([]*html.Node{tf.Head()}), "<head>"+
+		"<title>t</title>"+
+		"<link rel=\"preconnect\" href=\"https://fonts.example\"/>"+
+		"<link rel=\"preconnect\" href=\"https://cdn.example\"/>"+
+		"<link rel=\"preconnect\" href=\"https://known.example\"/>"+
+		"<link rel=\"stylesheet\" href=\"https://fonts.example/css?family=x\"/>"+
+		"<link rel=\"canonical\" href=\"https://other.example/page\"/>"+
+		"<link rel=\"alternate\" hreflang=\"fr\" href=\"https://fr.example/page\"/>"+
+		"</head>")
+
+	tree, _ = h5.NewFromString("<html><head></head><body>" +
+		"<script src=\"//cdn.example/a.js\"></script></body></html>")
+	tf = New(tree)
+	tf.AddResourceHints("www.example.com", true)
+	assertEqual(t, h5.RenderNodesToString([]*html.Node{tf.Head()}), "<head>"+
+		"<link rel=\"preconnect\" href=\"//cdn.example\"/>"+
+		"<link rel=\"dns-prefetch\" href=\"//cdn.example\"/>"+
+		"</head>")
+}
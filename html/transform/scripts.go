@@ -0,0 +1,125 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"code.google.com/p/go-html-transform/h5"
+)
+
+// isEventHandler returns true for inline event handler attributes such as
+// onclick.
+func isEventHandler(a html.Attribute) bool {
+	return a.Namespace == "" && len(a.Key) > 2 &&
+		strings.HasPrefix(strings.ToLower(a.Key), "on")
+}
+
+// ExtractEventHandlers moves inline event handler attributes such as onclick
+// to data-<prefix>-<event> attributes so a Content Security Policy can forbid
+// inline scripts. Elements with handlers that lack an id get a unique one.
+// It returns the handler code for each element keyed by id and then by event
+// so it can be attached with addEventListener instead.
+//
+//	<button onclick="go()"> => <button id="js-button" data-js-click="go()">
+func (t *Transformer) ExtractEventHandlers(prefix string) map[string]map[string]string {
+	handlers := make(map[string]map[string]string)
+	root := t.root()
+	if root == nil {
+		return handlers
+	}
+	ids := documentIds(t.Doc())
+	h5.WalkNodes(root, func(n *html.Node) {
+		if n.Type != html.ElementNode {
+			return
+		}
+		var events map[string]string
+		for _, a := range n.Attr {
+			if isEventHandler(a) {
+				if events == nil {
+					events = make(map[string]string)
+				}
+				events[strings.ToLower(a.Key[2:])] = a.Val
+			}
+		}
+		if events == nil {
+			return
+		}
+		// Existing attributes with the names the handlers move to are
+		// replaced rather than duplicated.
+		data := "data-" + prefix + "-"
+		removeAttrIf(n, func(a html.Attribute) bool {
+			if isEventHandler(a) || !strings.HasPrefix(a.Key, data) {
+				return false
+			}
+			_, ok := events[strings.TrimPrefix(a.Key, data)]
+			return ok
+		})
+		for i, a := range n.Attr {
+			if isEventHandler(a) {
+				n.Attr[i].Key = data + strings.ToLower(a.Key[2:])
+			}
+		}
+		id, ok := getAttr(n, "id")
+		if !ok || id == "" {
+			id = ids.unique(prefix + "-" + slugify(h5.Data(n)))
+			ModifyAttrib("id", id)(n)
+		}
+		handlers[id] = events
+	})
+	return handlers
+}
+
+// isBlockingScript returns true for a classic <script> that runs as soon as
+// it is parsed. Scripts with async or defer, modules, which are deferred,
+// and data blocks like application/ld+json aren't. Neither are scripts in
+// foreign content like <svg> which belong to the svg.
+func isBlockingScript(n *html.Node) bool {
+	if !isElement(n, "script") || n.Namespace != "" {
+		return false
+	}
+	if _, ok := getAttr(n, "async"); ok {
+		return false
+	}
+	if _, ok := getAttr(n, "defer"); ok {
+		return false
+	}
+	typ, _ := getAttr(n, "type")
+	switch strings.ToLower(strings.TrimSpace(typ)) {
+	case "", "text/javascript", "application/javascript", "text/ecmascript":
+		return true
+	}
+	return false
+}
+
+// DeferScriptsToBodyEnd moves the scripts in the document's <body> that
+// block rendering to the end of the body so the content before them isn't
+// held up while they load. Their relative order is kept so scripts that
+// depend on each other still run in the same order. If includeHead is true
+// the blocking scripts in the <head> are moved too, ahead of the ones from
+// the body. Scripts with async or defer, modules and data blocks stay where
+// they are, as do scripts in <template> content and inside <svg>.
+func (t *Transformer) DeferScriptsToBodyEnd(includeHead bool) {
+	body := t.Body()
+	if body == nil {
+		return
+	}
+	var scripts []*html.Node
+	collect := func(n *html.Node) {
+		if isBlockingScript(n) {
+			scripts = append(scripts, n)
+		}
+	}
+	if includeHead {
+		t.Apply(collect, "head script")
+	}
+	t.Apply(collect, "body script")
+	for _, s := range scripts {
+		s.Parent.RemoveChild(s)
+		body.AppendChild(s)
+	}
+}
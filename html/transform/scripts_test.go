@@ -0,0 +1,83 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"reflect"
+	"testing"
+
+	"code.google.com/p/go-html-transform/h5"
+)
+
+func TestExtractEventHandlers(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<button onclick=\"go()\">Go</button>" +
+		"<div id=\"home\" onMouseOver=\"hover()\" onclick=\"nav()\">Home</div>" +
+		"<p>plain</p>" +
+		"<div id=\"stale\" data-js-click=\"old()\" data-js-load=\"keep()\" onclick=\"new()\">x</div>" +
+		"</body></html>")
+	tf := New(tree)
+	handlers := tf.ExtractEventHandlers("js")
+	expected := map[string]map[string]string{
+		"js-button": {"click": "go()"},
+		"home":      {"mouseover": "hover()", "click": "nav()"},
+		"stale":     {"click": "new()"},
+	}
+	if !reflect.DeepEqual(handlers, expected) {
+		t.Errorf("Got: %v Expected: %v", handlers, expected)
+	}
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<button data-js-click=\"go()\" id=\"js-button\">Go</button>"+
+		"<div id=\"home\" data-js-mouseover=\"hover()\" data-js-click=\"nav()\">Home</div>"+
+		"<p>plain</p>"+
+		"<div id=\"stale\" data-js-load=\"keep()\" data-js-click=\"new()\">x</div>"+
+		"</body></html>")
+}
+
+func TestDeferScriptsToBodyEnd(t *testing.T) {
+	doc := "<html><head><script src=\"head.js\"></script></head><body>" +
+		"<script src=\"jquery.js\"></script>" +
+		"<p>text</p>" +
+		"<div><script>init()</script></div>" +
+		"<script async src=\"analytics.js\"></script>" +
+		"<script type=\"module\" src=\"app.js\"></script>" +
+		"<script type=\"application/ld+json\">{}</script>" +
+		"<template><script>inert()</script></template>" +
+		"<svg><script>s()</script></svg>" +
+		"<footer>end</footer>" +
+		"</body></html>"
+	tree, _ := h5.NewFromString(doc)
+	tf := New(tree)
+	tf.DeferScriptsToBodyEnd(false)
+	assertEqual(t, tf.String(), "<html><head><script src=\"head.js\"></script></head><body>"+
+		"<p>text</p>"+
+		"<div></div>"+
+		"<script async=\"\" src=\"analytics.js\"></script>"+
+		"<script type=\"module\" src=\"app.js\"></script>"+
+		"<script type=\"application/ld+json\">{}</script>"+
+		"<template><script>inert()</script></template>"+
+		"<svg><script>s()</script></svg>"+
+		"<footer>end</footer>"+
+		"<script src=\"jquery.js\"></script>"+
+		"<script>init()</script>"+
+		"</body></html>")
+
+	tree, _ = h5.NewFromString(doc)
+	tf = New(tree)
+	tf.DeferScriptsToBodyEnd(true)
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<p>text</p>"+
+		"<div></div>"+
+		"<script async=\"\" src=\"analytics.js\"></script>"+
+		"<script type=\"module\" src=\"app.js\"></script>"+
+		"<script type=\"application/ld+json\">{}</script>"+
+		"<template><script>inert()</script></template>"+
+		"<svg><script>s()</script></svg>"+
+		"<footer>end</footer>"+
+		"<script src=\"head.js\"></script>"+
+		"<script src=\"jquery.js\"></script>"+
+		"<script>init()</script>"+
+		"</body></html>")
+}
@@ -0,0 +1,289 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"code.google.com/p/go-html-transform/h5"
+)
+
+// declaration is a single property: value pair from a style attribute.
+type declaration struct {
+	prop, val string
+}
+
+// parseStyle splits the contents of a style attribute into its
+// declarations in order. Property names are lowercased. Declarations
+// without a property or value are dropped.
+func parseStyle(style string) []declaration {
+	var decls []declaration
+	for _, d := range strings.Split(style, ";") {
+		i := strings.Index(d, ":")
+		if i < 0 {
+			continue
+		}
+		prop := strings.ToLower(strings.TrimSpace(d[:i]))
+		val := strings.TrimSpace(d[i+1:])
+		if prop == "" || val == "" {
+			continue
+		}
+		decls = append(decls, declaration{prop, val})
+	}
+	return decls
+}
+
+// styleValue returns the value of the last declaration of prop in the style
+// attribute of n, lowercased and without any !important.
+func styleValue(n *html.Node, prop string) (string, bool) {
+	style, _ := getAttr(n, "style")
+	val, found := "", false
+	for _, d := range parseStyle(style) {
+		if d.prop == prop {
+			val, found = d.val, true
+		}
+	}
+	val = strings.ToLower(val)
+	if i := strings.Index(val, "!important"); i >= 0 {
+		val = strings.TrimSpace(val[:i])
+	}
+	return val, found
+}
+
+// IsHidden is a predicate that is true for elements hidden by their inline
+// style with display: none or visibility: hidden. Stylesheets and the
+// styles of ancestors aren't taken into account.
+//
+//	t.Apply(If(Not(IsHidden), AddClass("shown"), nil), "div")
+func IsHidden(n *html.Node) bool {
+	if v, ok := styleValue(n, "display"); ok && v == "none" {
+		return true
+	}
+	v, ok := styleValue(n, "visibility")
+	return ok && v == "hidden"
+}
+
+// mergeStyle adds the declarations to the style attribute of n. Properties
+// the style already declares keep their value.
+func mergeStyle(n *html.Node, decls []declaration) {
+	style, _ := getAttr(n, "style")
+	declared := make(map[string]bool)
+	for _, d := range parseStyle(style) {
+		declared[d.prop] = true
+	}
+	var add []string
+	for _, d := range decls {
+		if !declared[d.prop] {
+			add = append(add, d.prop+":"+d.val)
+			declared[d.prop] = true
+		}
+	}
+	if len(add) == 0 {
+		return
+	}
+	if style = strings.TrimRight(strings.TrimSpace(style), "; "); style != "" {
+		add = append([]string{style}, add...)
+	}
+	ModifyAttrib("style", strings.Join(add, ";"))(n)
+}
+
+// cssLength returns val with px appended if it is a plain number as used by
+// presentational attributes.
+func cssLength(val string) string {
+	if _, err := strconv.ParseFloat(val, 64); err == nil {
+		return val + "px"
+	}
+	return val
+}
+
+// alignStyle returns the css declarations equivalent to the align
+// attribute of n. Images and other embedded content float left or right and
+// are otherwise vertically aligned. Tables and rules are positioned as
+// blocks. Everything else aligns its text.
+func alignStyle(n *html.Node, val string) ([]declaration, bool) {
+	float := val == "left" || val == "right"
+	switch {
+	case isElement(n, "table") || isElement(n, "hr"):
+		if float {
+			return []declaration{{"float", val}}, true
+		}
+		if val == "center" {
+			return []declaration{{"margin-left", "auto"}, {"margin-right", "auto"}}, true
+		}
+		return nil, false
+	case alignReplaced[h5.Data(n)]:
+		if float {
+			return []declaration{{"float", val}}, true
+		}
+		return []declaration{{"vertical-align", val}}, true
+	}
+	return []declaration{{"text-align", val}}, true
+}
+
+// presentationalStyle returns the css declarations equivalent to a
+// deprecated presentational attribute.
+func presentationalStyle(n *html.Node, a html.Attribute) ([]declaration, bool) {
+	val := strings.TrimSpace(a.Val)
+	if val == "" {
+		return nil, false
+	}
+	switch strings.ToLower(a.Key) {
+	case "align":
+		return alignStyle(n, strings.ToLower(val))
+	case "valign":
+		return []declaration{{"vertical-align", strings.ToLower(val)}}, true
+	case "bgcolor":
+		return []declaration{{"background-color", val}}, true
+	case "border":
+		if w := cssLength(val); w != "0px" {
+			return []declaration{{"border", w + " solid"}}, true
+		}
+		return []declaration{{"border", "0"}}, true
+	}
+	return nil, false
+}
+
+// ConvertPresentationalAttribs creates a TransformFunc that replaces the
+// deprecated align, valign, bgcolor and border attributes of the node it
+// operates on with the equivalent inline style. Declarations already in
+// the style attribute take precedence over the converted ones.
+//
+//	<td bgcolor="red" valign="top"> => <td style="background-color:red;vertical-align:top">
+func ConvertPresentationalAttribs() TransformFunc {
+	return func(n *html.Node) {
+		var decls []declaration
+		removeAttrIf(n, func(a html.Attribute) bool {
+			ds, ok := presentationalStyle(n, a)
+			decls = append(decls, ds...)
+			return ok
+		})
+		mergeStyle(n, decls)
+	}
+}
+
+// alignReplaced are the elements whose align attribute positions the
+// element itself rather than aligning its text, so a text alignment class
+// would be wrong for them.
+var alignReplaced = map[string]bool{
+	"img":    true,
+	"iframe": true,
+	"object": true,
+	"embed":  true,
+	"input":  true,
+	"table":  true,
+}
+
+// ConvertLegacyAlignment creates a TransformFunc that replaces the legacy
+// alignment markup of the node it operates on with classes from the
+// caller's css framework. classes maps an alignment, like "center" or
+// "right", to the class to use for it. A <center> becomes a <div> with the
+// "center" class and an align attribute is replaced by the class for its
+// value. Alignments missing from classes are left as they are, as are the
+// align attributes of images, tables and other elements it positions.
+//
+//	f := ConvertLegacyAlignment(map[string]string{"center": "text-center"})
+//	t.Apply(f, "center")
+//	t.Apply(f, "[align]")
+//
+//	<center>foo</center> => <div class="text-center">foo</div>
+func ConvertLegacyAlignment(classes map[string]string) TransformFunc {
+	return func(n *html.Node) {
+		if n.Type != html.ElementNode {
+			return
+		}
+		if isElement(n, "center") {
+			if class, ok := classes["center"]; ok {
+				Rename("div")(n)
+				AddClass(class)(n)
+			}
+		}
+		if alignReplaced[h5.Data(n)] {
+			return
+		}
+		align, ok := getAttr(n, "align")
+		if !ok {
+			return
+		}
+		if class, ok := classes[strings.ToLower(strings.TrimSpace(align))]; ok {
+			removeAttr(n, "align")
+			AddClass(class)(n)
+		}
+	}
+}
+
+// fontSizes maps the 1-7 scale of <font size> to css font sizes. 3 is the
+// default size.
+var fontSizes = []string{
+	1: "x-small",
+	2: "small",
+	3: "medium",
+	4: "large",
+	5: "x-large",
+	6: "xx-large",
+	7: "xxx-large",
+}
+
+// fontStyle returns the css declaration equivalent to an attribute of a
+// <font>. Sizes relative to the default, like "+1", are resolved against 3
+// and sizes outside 1-7 are clamped.
+func fontStyle(a html.Attribute) (declaration, bool) {
+	val := strings.TrimSpace(a.Val)
+	if val == "" {
+		return declaration{}, false
+	}
+	switch a.Key {
+	case "color":
+		return declaration{"color", val}, true
+	case "face":
+		return declaration{"font-family", val}, true
+	case "size":
+		size, err := strconv.Atoi(strings.TrimPrefix(val, "+"))
+		if err != nil {
+			return declaration{}, false
+		}
+		if val[0] == '+' || val[0] == '-' {
+			size += 3
+		}
+		if size < 1 {
+			size = 1
+		} else if size > 7 {
+			size = 7
+		}
+		return declaration{"font-size", fontSizes[size]}, true
+	}
+	return declaration{}, false
+}
+
+// ConvertFontTags creates a TransformFunc that turns a <font> into a <span>
+// with its color, face and size attributes converted to inline style. Sizes
+// 1 to 7 become x-small, small, medium, large, x-large, xx-large and
+// xxx-large. Declarations already in the style attribute take precedence
+// and children are preserved. Other elements are left alone.
+//
+//	t.Apply(ConvertFontTags(), "font")
+//
+//	<font color="red" size="4">x</font> => <span style="color:red;font-size:large">x</span>
+func ConvertFontTags() TransformFunc {
+	return func(n *html.Node) {
+		if !isElement(n, "font") {
+			return
+		}
+		var decls []declaration
+		removeAttrIf(n, func(a html.Attribute) bool {
+			if a.Key != "color" && a.Key != "face" && a.Key != "size" {
+				return false
+			}
+			if d, ok := fontStyle(a); ok {
+				decls = append(decls, d)
+			}
+			return true
+		})
+		Rename("span")(n)
+		mergeStyle(n, decls)
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"testing"
+
+	"code.google.com/p/go-html-transform/h5"
+)
+
+func TestIsHidden(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<div style=\"display:none\">a</div>" +
+		"<div style=\"color: red; VISIBILITY: Hidden !important\">b</div>" +
+		"<div style=\"display: none; display: block\">c</div>" +
+		"<div style=\"visibility: visible\">d</div>" +
+		"<div>e</div>" +
+		"</body></html>")
+	tf := New(tree)
+	tf.Apply(If(Not(IsHidden), AddClass("shown"), nil), "div")
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<div style=\"display:none\">a</div>"+
+		"<div style=\"color: red; VISIBILITY: Hidden !important\">b</div>"+
+		"<div style=\"display: none; display: block\" class=\"shown\">c</div>"+
+		"<div style=\"visibility: visible\" class=\"shown\">d</div>"+
+		"<div class=\"shown\">e</div>"+
+		"</body></html>")
+}
+
+func TestConvertPresentationalAttribs(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<table border=\"1\" bgcolor=\"red\"><tr>" +
+		"<td valign=\"TOP\" align=\"center\" style=\"color: blue;\">a</td>" +
+		"<td bgcolor=\"red\" style=\"background-color: green\">b</td>" +
+		"</tr></table>" +
+		"<img src=\"a.png\" align=\"left\" border=\"0\">" +
+		"<p align=\"\">c</p>" +
+		"<table align=\"center\"><tr><td>d</td></tr></table>" +
+		"<table align=\"right\"><tr><td>e</td></tr></table>" +
+		"<hr align=\"justify\">" +
+		"<iframe src=\"f.html\" align=\"middle\"></iframe>" +
+		"</body></html>")
+	tf := New(tree)
+	tf.Apply(ConvertPresentationalAttribs(), "*")
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<table style=\"border:1px solid;background-color:red\"><tbody><tr>"+
+		"<td style=\"color: blue;vertical-align:top;text-align:center\">a</td>"+
+		"<td style=\"background-color: green\">b</td>"+
+		"</tr></tbody></table>"+
+		"<img src=\"a.png\" style=\"float:left;border:0\"/>"+
+		"<p align=\"\">c</p>"+
+		"<table style=\"margin-left:auto;margin-right:auto\"><tbody><tr><td>d</td></tr></tbody></table>"+
+		"<table style=\"float:right\"><tbody><tr><td>e</td></tr></tbody></table>"+
+		"<hr align=\"justify\"/>"+
+		"<iframe src=\"f.html\" style=\"vertical-align:middle\"></iframe>"+
+		"</body></html>")
+}
+
+func TestConvertLegacyAlignment(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<center><p>title</p></center>" +
+		"<p align=\"RIGHT\" class=\"note\">signed</p>" +
+		"<div align=\"justify\">long</div>" +
+		"<img src=\"a.png\" align=\"right\">" +
+		"</body></html>")
+	tf := New(tree)
+	f := ConvertLegacyAlignment(map[string]string{
+		"center": "text-center",
+		"right":  "text-end",
+	})
+	expected := "<html><head></head><body>" +
+		"<div class=\"text-center\"><p>title</p></div>" +
+		"<p class=\"note text-end\">signed</p>" +
+		"<div align=\"justify\">long</div>" +
+		"<img src=\"a.png\" align=\"right\"/>" +
+		"</body></html>"
+	for i := 0; i < 2; i++ {
+		tf.Apply(f, "center")
+		tf.Apply(f, "[align]")
+		assertEqual(t, tf.String(), expected)
+	}
+}
+
+func TestConvertFontTags(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<font color=\"red\" size=\"4\">big <b>red</b></font>" +
+		"<font face=\"Arial, sans-serif\" size=\"+2\" class=\"x\">face</font>" +
+		"<font size=\"-5\" style=\"font-size:9px\">tiny</font>" +
+		"<font size=\"huge\">bad</font>" +
+		"</body></html>")
+	tf := New(tree)
+	tf.Apply(ConvertFontTags(), "font")
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<span style=\"color:red;font-size:large\">big <b>red</b></span>"+
+		"<span class=\"x\" style=\"font-family:Arial, sans-serif;font-size:x-large\">face</span>"+
+		"<span style=\"font-size:9px\">tiny</span>"+
+		"<span>bad</span>"+
+		"</body></html>")
+}
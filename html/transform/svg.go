@@ -0,0 +1,101 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"golang.org/x/net/html"
+
+	"code.google.com/p/go-html-transform/h5"
+)
+
+// svgElement constructs an element in the svg namespace.
+func svgElement(tag string, attrs []html.Attribute) *html.Node {
+	return &html.Node{Type: html.ElementNode, Data: tag, Namespace: "svg", Attr: attrs}
+}
+
+// spriteCandidates returns the inline <svg> elements in the tree rooted at
+// n with drawing content of their own. Nested svgs, sprites and svgs that
+// only reference a symbol are skipped.
+func spriteCandidates(n *html.Node) []*html.Node {
+	var found []*html.Node
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if isElement(n, "svg") {
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if c.Type == html.ElementNode && !isElement(c, "use") &&
+					!isElement(c, "symbol") {
+					found = append(found, n)
+					break
+				}
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return found
+}
+
+// ExtractSVGSprite moves the content of the inline <svg> elements in the
+// document's body into <symbol> elements of a single hidden sprite <svg>
+// inserted at the start of the body. Each svg keeps its own attributes and
+// has its content replaced with a <use> referencing its symbol. Svgs with
+// the same content and viewBox share a symbol. Symbol ids start with prefix
+// and don't collide with existing ids. It returns the number of symbols
+// created.
+//
+//	<svg class="a" viewBox="0 0 8 8"><path d="..."/></svg>
+//	=> <svg class="a" viewBox="0 0 8 8"><use href="#icon"></use></svg>
+func (t *Transformer) ExtractSVGSprite(prefix string) int {
+	body := t.Body()
+	if body == nil {
+		return 0
+	}
+	svgs := spriteCandidates(body)
+	if len(svgs) == 0 {
+		return 0
+	}
+	ids := documentIds(t.Doc())
+	var symbols []*html.Node
+	var symbolIds []string
+	for _, svg := range svgs {
+		var attrs []html.Attribute
+		for _, key := range []string{"viewBox", "preserveAspectRatio"} {
+			if v, ok := getAttr(svg, key); ok {
+				attrs = append(attrs, html.Attribute{Key: key, Val: v})
+			}
+		}
+		symbol := svgElement("symbol", attrs)
+		for c := svg.FirstChild; c != nil; c = svg.FirstChild {
+			svg.RemoveChild(c)
+			symbol.AppendChild(c)
+		}
+		id := ""
+		for i, s := range symbols {
+			if h5.Equal(s, symbol) {
+				id = symbolIds[i]
+				break
+			}
+		}
+		if id == "" {
+			id = ids.unique(prefix)
+			symbols = append(symbols, symbol)
+			symbolIds = append(symbolIds, id)
+		}
+		svg.AppendChild(svgElement("use", []html.Attribute{{Key: "href", Val: "#" + id}}))
+	}
+	sprite := svgElement("svg", []html.Attribute{
+		{Key: "aria-hidden", Val: "true"},
+		{Key: "style", Val: "display:none"},
+	})
+	for i, s := range symbols {
+		ModifyAttrib("id", symbolIds[i])(s)
+		sprite.AppendChild(s)
+	}
+	body.InsertBefore(sprite, body.FirstChild)
+	return len(symbols)
+}
@@ -0,0 +1,38 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"testing"
+
+	"code.google.com/p/go-html-transform/h5"
+)
+
+func TestExtractSVGSprite(t *testing.T) {
+	icon := "<path d=\"M0 0h8v8z\"></path>"
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<p id=\"icon\">taken</p>" +
+		"<button><svg class=\"a\" viewBox=\"0 0 8 8\">" + icon + "</svg>Save</button>" +
+		"<button><svg class=\"b\" viewBox=\"0 0 8 8\">" + icon + "</svg>Save</button>" +
+		"<svg viewBox=\"0 0 16 16\">" + icon + "</svg>" +
+		"</body></html>")
+	tf := New(tree)
+	if n := tf.ExtractSVGSprite("icon"); n != 2 {
+		t.Errorf("Expected 2 symbols got %d", n)
+	}
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<svg aria-hidden=\"true\" style=\"display:none\">"+
+		"<symbol viewBox=\"0 0 8 8\" id=\"icon-1\">"+icon+"</symbol>"+
+		"<symbol viewBox=\"0 0 16 16\" id=\"icon-2\">"+icon+"</symbol>"+
+		"</svg>"+
+		"<p id=\"icon\">taken</p>"+
+		"<button><svg class=\"a\" viewBox=\"0 0 8 8\"><use href=\"#icon-1\"></use></svg>Save</button>"+
+		"<button><svg class=\"b\" viewBox=\"0 0 8 8\"><use href=\"#icon-1\"></use></svg>Save</button>"+
+		"<svg viewBox=\"0 0 16 16\"><use href=\"#icon-2\"></use></svg>"+
+		"</body></html>")
+	if n := tf.ExtractSVGSprite("icon"); n != 0 {
+		t.Errorf("Expected no new symbols the second time got %d", n)
+	}
+}
@@ -7,8 +7,10 @@ package transform
 import (
 	"fmt"
 	"io"
+	"strings"
 
 	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
 
 	"code.google.com/p/go-html-transform/css/selector"
 	"code.google.com/p/go-html-transform/h5"
@@ -38,12 +40,63 @@ func FirstMatch(cs ...Collector) CollectorFunc {
 	}
 }
 
+// SelectAnyClass returns a Collector that finds the elements in the tree
+// that have at least one of the classes, unlike a compound class selector
+// such as .a.b which requires all of them.
+//
+//	t.ApplyWithCollector(AddClass("promo"), SelectAnyClass("sale", "featured"))
+func SelectAnyClass(classes ...string) CollectorFunc {
+	return func(n *html.Node) []*html.Node {
+		var found []*html.Node
+		h5.WalkNodes(n, func(n *html.Node) {
+			if n.Type != html.ElementNode {
+				return
+			}
+			for _, c := range classes {
+				if HasClass(c)(n) {
+					found = append(found, n)
+					return
+				}
+			}
+		})
+		return found
+	}
+}
+
+// ClosestOrSelf returns n if it matches the CSS3 selector or else the
+// nearest ancestor of n that does, like the DOM's Element.closest. It returns
+// nil if there is no match.
+func ClosestOrSelf(n *html.Node, sel string) (*html.Node, error) {
+	chn, err := selector.Selector(sel)
+	if err != nil {
+		return nil, err
+	}
+	for ; n != nil; n = n.Parent {
+		if chn.Match(n) {
+			return n, nil
+		}
+	}
+	return nil, nil
+}
+
+// Closest returns the nearest ancestor of n that matches the CSS3 selector.
+// Unlike ClosestOrSelf n itself is never returned. It returns nil if there is
+// no match.
+func Closest(n *html.Node, sel string) (*html.Node, error) {
+	if n == nil {
+		return nil, nil
+	}
+	return ClosestOrSelf(n.Parent, sel)
+}
+
 // The TransformFunc type is the type of a html.Node transformation function.
 type TransformFunc func(*html.Node)
 
 // Transformer encapsulates a document under transformation.
 type Transformer struct {
 	doc *h5.Tree
+	// scopes is the stack of nodes pushed by Within.
+	scopes []*html.Node
 }
 
 func NewFromReader(rdr io.Reader) (*Transformer, error) {
@@ -70,6 +123,64 @@ func (t *Transformer) Doc() *html.Node {
 	return t.doc.Top()
 }
 
+// documentElement returns the root <html> element of the document.
+func (t *Transformer) documentElement() *html.Node {
+	for c := t.Doc().FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && h5.Data(c) == "html" {
+			return c
+		}
+	}
+	return nil
+}
+
+// documentChild returns the first child element of the root <html>
+// element with the tag.
+func (t *Transformer) documentChild(tag string) *html.Node {
+	root := t.documentElement()
+	if root == nil {
+		return nil
+	}
+	for c := root.FirstChild; c != nil; c = c.NextSibling {
+		if isElement(c, tag) {
+			return c
+		}
+	}
+	return nil
+}
+
+// Head returns the <head> element of the document or nil if there isn't one.
+func (t *Transformer) Head() *html.Node {
+	return t.documentChild("head")
+}
+
+// Body returns the <body> element of the document or nil if there isn't one.
+func (t *Transformer) Body() *html.Node {
+	return t.documentChild("body")
+}
+
+// SetDocumentLang sets the lang attribute of the root <html> element
+// replacing any existing value. Selectors using :lang will match the
+// document's content accordingly.
+func (t *Transformer) SetDocumentLang(lang string) {
+	if n := t.documentElement(); n != nil {
+		ModifyAttrib("lang", lang)(n)
+	}
+}
+
+// IsAttached returns true if n is part of the document under
+// transformation, that is following its parents leads to the document's
+// root. Nodes a transform has removed, or that were never inserted, aren't
+// attached.
+func (t *Transformer) IsAttached(n *html.Node) bool {
+	root := t.Doc()
+	for ; n != nil; n = n.Parent {
+		if n == root {
+			return true
+		}
+	}
+	return false
+}
+
 func (t *Transformer) Render(w io.Writer) error {
 	return t.doc.Render(w)
 }
@@ -82,6 +193,39 @@ func (t *Transformer) Clone() *Transformer {
 	return New(t.doc)
 }
 
+// Snapshot returns a detached copy of the document under transformation
+// that can later be passed to Restore. Changes made after the snapshot is
+// taken don't affect it.
+func (t *Transformer) Snapshot() h5.Tree {
+	return t.doc.Clone()
+}
+
+// Restore reverts the document under transformation to a Snapshot.
+// The snapshot is copied so it can be restored more than once. Any scopes
+// pushed by Within are discarded.
+func (t *Transformer) Restore(snap h5.Tree) {
+	clone := snap.Clone()
+	t.doc = &clone
+	t.scopes = nil
+}
+
+// Tidy normalizes the nesting of the document by serializing it and parsing
+// it again, so elements moved into places the HTML parsing rules don't allow,
+// like a <div> inside a <p>, end up where a browser would put them. The
+// document keeps rendering its attributes the same way. Any scopes pushed
+// by Within are discarded.
+//
+//	<p>foo<div>bar</div></p> => <p>foo</p><div>bar</div><p></p>
+func (t *Transformer) Tidy() error {
+	tree, err := t.doc.Reparse()
+	if err != nil {
+		return err
+	}
+	t.doc = tree
+	t.scopes = nil
+	return nil
+}
+
 func applyFuncToCollector(f TransformFunc, n *html.Node, sel Collector) {
 	for _, nn := range sel.Find(n) {
 		f(nn)
@@ -96,6 +240,36 @@ func (t *Transformer) Apply(f TransformFunc, sel string) error {
 	return err
 }
 
+// ApplyIndexed calls f with each node matched by the CSS3 selector along
+// with its position in the matches, starting from 0, in document order.
+func (t *Transformer) ApplyIndexed(f func(i int, n *html.Node), sel string) error {
+	sq, err := selector.Selector(sel)
+	if err != nil {
+		return err
+	}
+	n := t.root()
+	if n == nil {
+		return nil
+	}
+	for i, nn := range sq.Find(n) {
+		f(i, nn)
+	}
+	return nil
+}
+
+// ApplyFirst applies a TransformFunc to only the first node in document
+// order matched by the CSS3 selector. It does nothing if there is no match.
+func (t *Transformer) ApplyFirst(f TransformFunc, sel string) error {
+	n, err := first(t.root(), sel)
+	if err != nil {
+		return err
+	}
+	if n != nil {
+		f(n)
+	}
+	return nil
+}
+
 func (t *Transformer) ApplyToFirstMatch(f TransformFunc, sels ...string) error {
 	cs := make([]Collector, 0, len(sels))
 	for _, sel := range sels {
@@ -111,8 +285,52 @@ func (t *Transformer) ApplyToFirstMatch(f TransformFunc, sels ...string) error {
 
 // ApplyWithCollector applies a TransformFunc to the tree using a Collector.
 func (t *Transformer) ApplyWithCollector(f TransformFunc, coll Collector) {
+	n := t.root()
+	if n == nil {
+		return
+	}
 	// TODO come up with a way to walk tree once?
-	applyFuncToCollector(f, t.Doc(), coll)
+	applyFuncToCollector(f, n, coll)
+}
+
+// root returns the node that Apply calls operate on. That is the innermost
+// scope pushed by Within or the whole document if there isn't one.
+func (t *Transformer) root() *html.Node {
+	if l := len(t.scopes); l > 0 {
+		return t.scopes[l-1]
+	}
+	return t.Doc()
+}
+
+// Within scopes all subsequent Apply calls to the first node matched by the
+// CSS3 Selector in the current scope until the matching call to End.
+// If the selector is invalid or doesn't match anything an empty scope is
+// pushed and Apply calls match nothing until End is called.
+//
+//	t.Within("table")
+//	t.Apply(ModifyAttrib("class", "data"), "td")
+//	t.Within("tr").Apply(...)
+//	t.End().End()
+func (t *Transformer) Within(sel string) *Transformer {
+	var scope *html.Node
+	if n := t.root(); n != nil {
+		if chn, err := selector.Selector(sel); err == nil {
+			if ns := chn.Find(n); len(ns) > 0 {
+				scope = ns[0]
+			}
+		}
+	}
+	t.scopes = append(t.scopes, scope)
+	return t
+}
+
+// End pops the scope pushed by the last call to Within. Calling End
+// without a matching Within is a no-op.
+func (t *Transformer) End() *Transformer {
+	if l := len(t.scopes); l > 0 {
+		t.scopes = t.scopes[:l-1]
+	}
+	return t
 }
 
 // Transform is a bundle of selectors and a transform func. It forms a
@@ -166,6 +384,20 @@ func AppendChildren(cs ...*html.Node) TransformFunc {
 	}
 }
 
+// AppendGenerated creates a TransformFunc that appends count children to the
+// node it operates on. The children are produced one at a time by gen in
+// order, so no slice of templates needs to be built up front. Nil children
+// are skipped.
+func AppendGenerated(count int, gen func(i int) *html.Node) TransformFunc {
+	return func(n *html.Node) {
+		for i := 0; i < count; i++ {
+			if c := gen(i); c != nil {
+				n.AppendChild(c)
+			}
+		}
+	}
+}
+
 // PrependChildren creates a TransformFunc that prepends the Children passed in.
 func PrependChildren(cs ...*html.Node) TransformFunc {
 	return func(n *html.Node) {
@@ -200,6 +432,11 @@ func ReplaceChildren(ns ...*html.Node) TransformFunc {
 	}
 }
 
+// isElement returns true if n is an element with the given tag name.
+func isElement(n *html.Node, tag string) bool {
+	return n.Type == html.ElementNode && h5.Data(n) == tag
+}
+
 func nodeToString(n *html.Node) string {
 	t := h5.NewTree(n)
 	return t.String()
@@ -222,6 +459,29 @@ func Replace(ns ...*html.Node) TransformFunc {
 	}
 }
 
+// Wrap creates a TransformFunc that wraps the node it operates on in a copy
+// of wrapper. The node is appended to the copied wrapper's children and the
+// copy takes the node's place in the tree.
+func Wrap(wrapper *html.Node) TransformFunc {
+	return func(n *html.Node) {
+		w := h5.CloneNode(wrapper)
+		if p := n.Parent; p != nil {
+			p.InsertBefore(w, n)
+			p.RemoveChild(n)
+		}
+		w.AppendChild(n)
+	}
+}
+
+// Rename creates a TransformFunc that changes the tag name of the element it
+// operates on keeping its attributes and children.
+func Rename(tag string) TransformFunc {
+	return func(n *html.Node) {
+		n.Data = tag
+		n.DataAtom = atom.Lookup([]byte(tag))
+	}
+}
+
 // DoAll returns a TransformFunc that combines all the TransformFuncs that are
 // passed in. Doing each transform in order.
 func DoAll(fs ...TransformFunc) TransformFunc {
@@ -311,6 +571,65 @@ func TransformAttrib(key string, f func(string) string) TransformFunc {
 	}
 }
 
+// RemoveAttrib creates a TransformFunc that removes the attribute from the
+// node it operates on.
+func RemoveAttrib(key string) TransformFunc {
+	return func(n *html.Node) {
+		removeAttr(n, key)
+	}
+}
+
+// AddClass creates a TransformFunc that adds the classes to the class
+// attribute of the node it operates on if they aren't already there.
+func AddClass(classes ...string) TransformFunc {
+	return func(n *html.Node) {
+		val, _ := getAttr(n, "class")
+		list := strings.Fields(val)
+		for _, c := range classes {
+			if !attrContains(c, val) {
+				list = append(list, c)
+				val += " " + c
+			}
+		}
+		ModifyAttrib("class", strings.Join(list, " "))(n)
+	}
+}
+
+// uniqueTokens returns the tokens without duplicates keeping the first of
+// each.
+func uniqueTokens(tokens []string) []string {
+	var list []string
+	seen := make(map[string]bool)
+	for _, tok := range tokens {
+		if !seen[tok] {
+			seen[tok] = true
+			list = append(list, tok)
+		}
+	}
+	return list
+}
+
+// MergeClasses creates a TransformFunc that is like AddClass but also
+// normalizes the class attribute of the node it operates on. Duplicate
+// classes are dropped, keeping the first, and the rest are separated by
+// single spaces. A class attribute left empty is removed.
+//
+//	class=" btn  btn-lg btn " => class="btn btn-lg"
+func MergeClasses(add ...string) TransformFunc {
+	return func(n *html.Node) {
+		val, ok := getAttr(n, "class")
+		if !ok && len(add) == 0 {
+			return
+		}
+		list := uniqueTokens(append(strings.Fields(val), add...))
+		if len(list) == 0 {
+			removeAttr(n, "class")
+			return
+		}
+		ModifyAttrib("class", strings.Join(list, " "))(n)
+	}
+}
+
 // Trace is a debugging wrapper for transform funcs.
 // It calls traceFunc with debugging information before and after the
 // TransformFunc is applied.
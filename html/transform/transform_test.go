@@ -7,6 +7,9 @@ package transform
 
 import (
 	"code.google.com/p/go-html-transform/h5"
+	"golang.org/x/net/html"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -63,6 +66,151 @@ func TestTransformApplyMulti(t *testing.T) {
 	assertEqual(t, newDoc, "<html><head></head><body><div id=\"bar\"></div></body></html>")
 }
 
+func TestTransformSnapshotRestore(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body><div id=\"foo\"></div></body></html>")
+	tf := New(tree)
+	tf.Apply(AppendChildren(h5.Text("bar")), "div")
+	snap := tf.Snapshot()
+	tf.Apply(AppendChildren(h5.Text("baz")), "div")
+	assertEqual(t, snap.String(), "<html><head></head><body><div id=\"foo\">bar</div></body></html>")
+	tf.Restore(snap)
+	assertEqual(t, tf.String(), "<html><head></head><body><div id=\"foo\">bar</div></body></html>")
+	tf.Apply(AppendChildren(h5.Text("quux")), "div")
+	assertEqual(t, snap.String(), "<html><head></head><body><div id=\"foo\">bar</div></body></html>")
+	tf.Restore(snap)
+	assertEqual(t, tf.String(), "<html><head></head><body><div id=\"foo\">bar</div></body></html>")
+}
+
+func TestTransformApplyIndexed(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body><p>a</p><div><p>b</p></div><p>c</p></body></html>")
+	tf := New(tree)
+	err := tf.ApplyIndexed(func(i int, n *html.Node) {
+		ModifyAttrib("id", "p"+strconv.Itoa(i))(n)
+	}, "p")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<p id=\"p0\">a</p><div><p id=\"p1\">b</p></div><p id=\"p2\">c</p></body></html>")
+	if err := tf.ApplyIndexed(func(int, *html.Node) {}, "p:bogus"); err == nil {
+		t.Error("Expected an error for an invalid selector")
+	}
+}
+
+func TestTransformApplyFirst(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body><div><p>a</p></div><p>b</p></body></html>")
+	tf := New(tree)
+	if err := tf.ApplyFirst(AddClass("first"), "p"); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if err := tf.ApplyFirst(AddClass("first"), "span"); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<div><p class=\"first\">a</p></div><p>b</p></body></html>")
+	if err := tf.ApplyFirst(AddClass("first"), "p:bogus"); err == nil {
+		t.Error("Expected an error for an invalid selector")
+	}
+}
+
+func TestTransformTidy(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body><p id=\"foo\">foo</p></body></html>")
+	tf := New(tree)
+	tf.Apply(AppendChildren(h5.Div("", nil, h5.Text("bar"))), "p")
+	assertEqual(t, tf.String(), "<html><head></head><body><p id=\"foo\">foo<div>bar</div></p></body></html>")
+	if err := tf.Tidy(); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	assertEqual(t, tf.String(), "<html><head></head><body><p id=\"foo\">foo</p><div>bar</div><p></p></body></html>")
+	tf.Apply(AppendChildren(h5.Text("baz")), "div")
+	assertEqual(t, tf.String(), "<html><head></head><body><p id=\"foo\">foo</p><div>barbaz</div><p></p></body></html>")
+
+	// Tidy keeps bare booleans.
+	tree, _ = h5.NewFromString("<html><body><p><input checked=\"checked\"><div>x</div></p></body></html>")
+	tf = New(tree)
+	tf.CanonicalizeBooleans()
+	if err := tf.Tidy(); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	assertEqual(t, tf.String(), "<html><head></head><body><p><input checked/></p><div>x</div><p></p></body></html>")
+}
+
+func TestSelectAnyClass(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<p class=\"item sale\">a</p><p class=\"item\">b</p>" +
+		"<p class=\"featured\">c</p><p class=\"sale featured\">d</p>" +
+		"</body></html>")
+	tf := New(tree)
+	var texts []string
+	for _, n := range SelectAnyClass("sale", "featured").Find(tf.Doc()) {
+		texts = append(texts, h5.TextContent(n))
+	}
+	assertEqual(t, strings.Join(texts, ","), "a,c,d")
+	if ns := SelectAnyClass().Find(tf.Doc()); len(ns) != 0 {
+		t.Errorf("Expected no matches without classes got %d", len(ns))
+	}
+}
+
+func TestClosest(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body><div class=\"box\">" +
+		"<div class=\"box inner\"><a>foo</a></div></div></body></html>")
+	tf := New(tree)
+	var inner, a *html.Node
+	tf.Apply(func(n *html.Node) { inner = n }, ".inner")
+	tf.Apply(func(n *html.Node) { a = n }, "a")
+	n, _ := ClosestOrSelf(inner, "div.box")
+	assertEqual(t, n, inner)
+	n, _ = Closest(inner, "div.box")
+	assertEqual(t, n, inner.Parent)
+	n, _ = ClosestOrSelf(a, "body>div")
+	assertEqual(t, n, inner.Parent)
+	n, _ = Closest(a, "ul")
+	assertEqual(t, n, (*html.Node)(nil))
+	if _, err := Closest(a, ">ul"); err == nil {
+		t.Errorf("Closest didn't return an error for an invalid selector")
+	}
+}
+
+func TestSetDocumentLang(t *testing.T) {
+	tree, _ := h5.NewFromString("<html lang=\"en\"><body><p>foo</p></body></html>")
+	tf := New(tree)
+	tf.SetDocumentLang("de-CH")
+	tf.Apply(ModifyAttrib("class", "german"), "p:lang(de)")
+	assertEqual(t, tf.String(), "<html lang=\"de-CH\"><head></head><body>"+
+		"<p class=\"german\">foo</p></body></html>")
+}
+
+func TestHeadBody(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><head><title>t</title></head><body><p>b</p></body></html>")
+	tf := New(tree)
+	assertEqual(t, h5.RenderNodesToString([]*html.Node{tf.Head()}), "<head><title>t</title></head>")
+	assertEqual(t, h5.RenderNodesToString([]*html.Node{tf.Body()}), "<body><p>b</p></body>")
+	div := h5.NewTree(h5.Div("", nil))
+	if n := New(&div).Body(); n != nil {
+		t.Errorf("Expected no body got %v", n)
+	}
+}
+
+func TestIsAttached(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body><div><p>foo</p></div></body></html>")
+	tf := New(tree)
+	var p *html.Node
+	tf.Apply(func(n *html.Node) { p = n }, "p")
+	if !tf.IsAttached(p) || !tf.IsAttached(tf.Doc()) {
+		t.Error("Expected the p and the document to be attached")
+	}
+	tf.Apply(Replace(h5.Text("bar")), "div")
+	if tf.IsAttached(p) {
+		t.Error("Expected the p to be detached after its parent was replaced")
+	}
+	if tf.IsAttached(h5.Text("new")) || tf.IsAttached(nil) {
+		t.Error("Expected new and nil nodes to be detached")
+	}
+	if New(tree).IsAttached(tf.Doc()) {
+		t.Error("Expected another document's nodes to be detached")
+	}
+}
+
 func TestAppendChildren(t *testing.T) {
 	node := h5.Anchor("", "")
 	child := h5.Text("foo ")
@@ -71,6 +219,20 @@ func TestAppendChildren(t *testing.T) {
 	assertEqual(t, h5.NewTree(node).String(), "<a>foo bar</a>")
 }
 
+func TestAppendGenerated(t *testing.T) {
+	node := h5.Element("table", nil)
+	AppendGenerated(1000, func(i int) *html.Node {
+		return h5.Element("tr", nil, h5.Text(strconv.Itoa(i)))
+	})(node)
+	i := 0
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		assertEqual(t, c.Parent, node)
+		assertEqual(t, h5.Data(c.FirstChild), strconv.Itoa(i))
+		i++
+	}
+	assertEqual(t, i, 1000)
+}
+
 func TestRemoveChildren(t *testing.T) {
 	node := h5.Anchor("", "foo")
 	RemoveChildren()(node)
@@ -130,6 +292,24 @@ func TestReplaceSpliceOnRootNode(t *testing.T) {
 	assertEqual(t, h5.Data(doc.FirstChild.FirstChild), "foo")
 }
 
+func TestWrap(t *testing.T) {
+	node := h5.Div("", nil, h5.Text("foo"), h5.Anchor("/", "bar"), h5.Text("baz"))
+	wrapper := h5.Element("p", nil, h5.Text("see "))
+	Wrap(wrapper)(node.FirstChild.NextSibling)
+	assertEqual(t, h5.NewTree(node).String(),
+		"<div>foo<p>see <a href=\"/\">bar</a></p>baz</div>")
+	assertEqual(t, node.FirstChild.NextSibling.FirstChild.NextSibling.Parent,
+		node.FirstChild.NextSibling)
+	assertEqual(t, h5.NewTree(wrapper).String(), "<p>see </p>")
+}
+
+func TestRename(t *testing.T) {
+	node := h5.Div("foo", nil, h5.Text("bar"))
+	Rename("section")(node)
+	assertEqual(t, h5.NewTree(node).String(),
+		"<section id=\"foo\">bar</section>")
+}
+
 func TestModifyAttrib(t *testing.T) {
 	node := h5.Anchor("", "")
 	ModifyAttrib("id", "bar")(node)
@@ -147,6 +327,50 @@ func TestTransformAttrib(t *testing.T) {
 	assertEqual(t, node.Attr[0].Val, "bar")
 }
 
+func TestRemoveAttrib(t *testing.T) {
+	node := h5.Anchor("/", "")
+	ModifyAttrib("id", "foo")(node)
+	RemoveAttrib("href")(node)
+	assertEqual(t, h5.NewTree(node).String(), "<a id=\"foo\"></a>")
+}
+
+func TestAddClass(t *testing.T) {
+	node := h5.Anchor("", "")
+	AddClass("foo")(node)
+	assertEqual(t, node.Attr[0].Val, "foo")
+	AddClass("bar", "foo", "baz")(node)
+	assertEqual(t, node.Attr[0].Val, "foo bar baz")
+}
+
+func TestMergeClasses(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<div class=\" btn  btn-lg\tbtn \">a</div>" +
+		"<div class=\"card\">b</div>" +
+		"<div class=\"  \">c</div>" +
+		"<div>d</div>" +
+		"</body></html>")
+	tf := New(tree)
+	expected := "<html><head></head><body>" +
+		"<div class=\"btn btn-lg card\">a</div>" +
+		"<div class=\"card\">b</div>" +
+		"<div class=\"card\">c</div>" +
+		"<div class=\"card\">d</div>" +
+		"</body></html>"
+	tf.Apply(MergeClasses("card"), "div")
+	assertEqual(t, tf.String(), expected)
+	tf.Apply(MergeClasses("card"), "div")
+	assertEqual(t, tf.String(), expected)
+
+	node := h5.Element("div", []html.Attribute{{Key: "class", Val: " a a "}})
+	MergeClasses()(node)
+	assertEqual(t, node.Attr[0].Val, "a")
+	node = h5.Element("div", []html.Attribute{{Key: "class", Val: "  "}})
+	MergeClasses()(node)
+	if len(node.Attr) != 0 {
+		t.Errorf("Expected the empty class attribute to be removed got %v", node.Attr)
+	}
+}
+
 func TestDoAll(t *testing.T) {
 	tree, _ := h5.NewFromString("<div id=\"foo\">foo</div><")
 	node := tree.Top()
@@ -196,6 +420,27 @@ func TestTransformSubtransforms(t *testing.T) {
 
 }
 
+func TestTransformWithin(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<table><tr><td>a</td></tr><tr><td>b</td></tr></table>" +
+		"<td>c</td></body></html>")
+	tf := New(tree)
+	tf.Within("table").Apply(ModifyAttrib("class", "cell"), "td")
+	tf.Within("tr").Apply(ModifyAttrib("class", "first"), "td")
+	tf.End().End().End()
+	tf.Apply(AppendChildren(h5.Text("!")), "body")
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<table><tbody><tr><td class=\"first\">a</td></tr>"+
+		"<tr><td class=\"cell\">b</td></tr></tbody></table>"+
+		"c!</body></html>")
+	tf.Within("ul").Apply(ModifyAttrib("class", "none"), "td")
+	tf.End()
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<table><tbody><tr><td class=\"first\">a</td></tr>"+
+		"<tr><td class=\"cell\">b</td></tr></tbody></table>"+
+		"c!</body></html>")
+}
+
 // TODO(jwall): benchmarking tests
 func BenchmarkTransformApply(b *testing.B) {
 	for i := 0; i < b.N; i++ {
@@ -0,0 +1,166 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// matchParam returns true if name is one of params. A param ending in '*'
+// matches any name with that prefix.
+func matchParam(name string, params []string) bool {
+	for _, p := range params {
+		if strings.HasSuffix(p, "*") {
+			if strings.HasPrefix(name, p[:len(p)-1]) {
+				return true
+			}
+		} else if name == p {
+			return true
+		}
+	}
+	return false
+}
+
+func stripQueryParams(val string, params []string) string {
+	u, err := url.Parse(val)
+	if err != nil || u.RawQuery == "" {
+		return val
+	}
+	// Filter the raw query by hand so the order and encoding of the
+	// remaining parameters is preserved.
+	var kept []string
+	all := strings.Split(u.RawQuery, "&")
+	for _, p := range all {
+		name := p
+		if i := strings.Index(p, "="); i >= 0 {
+			name = p[:i]
+		}
+		if name, err = url.QueryUnescape(name); err != nil || !matchParam(name, params) {
+			kept = append(kept, p)
+		}
+	}
+	if len(kept) == len(all) {
+		return val
+	}
+	u.RawQuery = strings.Join(kept, "&")
+	return u.String()
+}
+
+// StripQueryParams creates a TransformFunc that removes the named query
+// parameters from the href and src attributes of the node it operates on.
+// A name ending in '*' removes every parameter with that prefix. Other
+// parameters and the fragment are preserved.
+//
+//	t.Apply(StripQueryParams("utm_*", "fbclid"), "a")
+func StripQueryParams(params ...string) TransformFunc {
+	strip := func(val string) string {
+		return stripQueryParams(val, params)
+	}
+	return func(n *html.Node) {
+		TransformAttrib("href", strip)(n)
+		TransformAttrib("src", strip)(n)
+	}
+}
+
+// srcsetCandidate is one url and its optional width or density descriptor
+// from a srcset attribute.
+type srcsetCandidate struct {
+	url, descriptor string
+}
+
+// parseSrcset splits a srcset attribute into its candidates. Commas inside
+// a url, as in data: URIs, don't split it.
+func parseSrcset(val string) []srcsetCandidate {
+	var cs []srcsetCandidate
+	for val != "" {
+		val = strings.TrimLeft(val, " \t\n\f\r,")
+		if val == "" {
+			break
+		}
+		end := strings.IndexAny(val, " \t\n\f\r")
+		if end < 0 {
+			end = len(val)
+		}
+		c := srcsetCandidate{url: val[:end]}
+		val = val[end:]
+		if strings.HasSuffix(c.url, ",") {
+			c.url = strings.TrimRight(c.url, ",")
+		} else {
+			end = strings.Index(val, ",")
+			if end < 0 {
+				end = len(val)
+			}
+			c.descriptor = strings.TrimSpace(val[:end])
+			val = val[end:]
+		}
+		cs = append(cs, c)
+	}
+	return cs
+}
+
+// formatSrcset joins candidates back into a srcset attribute.
+func formatSrcset(cs []srcsetCandidate) string {
+	parts := make([]string, len(cs))
+	for i, c := range cs {
+		parts[i] = c.url
+		if c.descriptor != "" {
+			parts[i] += " " + c.descriptor
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// mapSrcset returns the srcset with f applied to each candidate's url.
+func mapSrcset(val string, f func(string) string) string {
+	cs := parseSrcset(val)
+	for i := range cs {
+		cs[i].url = f(cs[i].url)
+	}
+	return formatSrcset(cs)
+}
+
+// SwapThemeAssets creates a TransformFunc that replaces from with to in the
+// src and in every url of the srcset of the node it operates on. It is
+// meant for building a variant of a page that uses a different set of
+// theme assets.
+//
+//	t.Apply(SwapThemeAssets("/light/", "/dark/"), "img")
+//	t.Apply(SwapThemeAssets("/light/", "/dark/"), "source")
+func SwapThemeAssets(from, to string) TransformFunc {
+	swap := func(val string) string {
+		return strings.Replace(val, from, to, -1)
+	}
+	return func(n *html.Node) {
+		TransformAttrib("src", swap)(n)
+		TransformAttrib("srcset", func(val string) string {
+			return mapSrcset(val, swap)
+		})(n)
+	}
+}
+
+// RewriteURLs creates a TransformFunc that replaces the href and src of
+// the node it operates on, and each url in its srcset, with the result of
+// calling f with them. The srcset descriptors are preserved.
+//
+//	base, _ := url.Parse("http://example.com/post/")
+//	t.Apply(RewriteURLs(func(s string) string {
+//		u, err := base.Parse(s)
+//		if err != nil {
+//			return s
+//		}
+//		return u.String()
+//	}), "img")
+func RewriteURLs(f func(string) string) TransformFunc {
+	return func(n *html.Node) {
+		TransformAttrib("href", f)(n)
+		TransformAttrib("src", f)(n)
+		TransformAttrib("srcset", func(val string) string {
+			return mapSrcset(val, f)
+		})(n)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2010 Jeremy Wall (jeremy@marzhillstudios.com)
+// Use of this source code is governed by the Artistic License 2.0.
+// That License is included in the LICENSE file.
+
+package transform
+
+import (
+	"net/url"
+	"testing"
+
+	"code.google.com/p/go-html-transform/h5"
+)
+
+func TestStripQueryParams(t *testing.T) {
+	node := h5.Anchor("http://example.com/p?utm_source=x&id=1&fbclid=abc&utm_medium=y&q=a%20b#top", "")
+	StripQueryParams("utm_*", "fbclid")(node)
+	assertEqual(t, node.Attr[0].Val, "http://example.com/p?id=1&q=a%20b#top")
+	node = h5.Anchor("/p?utm_source=x#top", "")
+	StripQueryParams("utm_*")(node)
+	assertEqual(t, node.Attr[0].Val, "/p#top")
+	node = h5.Anchor("/p#top", "")
+	StripQueryParams("utm_*")(node)
+	assertEqual(t, node.Attr[0].Val, "/p#top")
+}
+
+func TestParseSrcset(t *testing.T) {
+	for val, expected := range map[string]string{
+		"a.png":                            "a.png",
+		"a.png 1x,b.png 2x":                "a.png 1x, b.png 2x",
+		"  a.png  100w ,\n b.png   200w  ": "a.png 100w, b.png 200w",
+		"a.png, b.png 2x":                  "a.png, b.png 2x",
+		"data:image/png;base64,AAA= 1x":    "data:image/png;base64,AAA= 1x",
+	} {
+		if got := formatSrcset(parseSrcset(val)); got != expected {
+			t.Errorf("%q Got: %q Expected: %q", val, got, expected)
+		}
+	}
+}
+
+func TestSwapThemeAssets(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body><picture>" +
+		"<source srcset=\"/light/a.webp 1x, /light/a@2x.webp 2x\">" +
+		"<img src=\"/light/a.png\" srcset=\"/light/a.png 480w,/other/a.png 800w\" alt=\"/light/\">" +
+		"</picture></body></html>")
+	tf := New(tree)
+	tf.Apply(SwapThemeAssets("/light/", "/dark/"), "source")
+	tf.Apply(SwapThemeAssets("/light/", "/dark/"), "img")
+	assertEqual(t, tf.String(), "<html><head></head><body><picture>"+
+		"<source srcset=\"/dark/a.webp 1x, /dark/a@2x.webp 2x\"/>"+
+		"<img src=\"/dark/a.png\" srcset=\"/dark/a.png 480w, /other/a.png 800w\" alt=\"/light/\"/>"+
+		"</picture></body></html>")
+}
+
+func TestRewriteURLs(t *testing.T) {
+	tree, _ := h5.NewFromString("<html><body>" +
+		"<a href=\"../about\">about</a>" +
+		"<img src=\"a.png\" srcset=\"a.png 1x, img/a@2x.png 2x\">" +
+		"<picture><source srcset=\"/b.webp 640w,b-big.webp 1280w\"></picture>" +
+		"</body></html>")
+	tf := New(tree)
+	base, _ := url.Parse("http://example.com/post/")
+	f := RewriteURLs(func(s string) string {
+		u, err := base.Parse(s)
+		if err != nil {
+			return s
+		}
+		return u.String()
+	})
+	tf.Apply(f, "a")
+	tf.Apply(f, "img")
+	tf.Apply(f, "source")
+	assertEqual(t, tf.String(), "<html><head></head><body>"+
+		"<a href=\"http://example.com/about\">about</a>"+
+		"<img src=\"http://example.com/post/a.png\" "+
+		"srcset=\"http://example.com/post/a.png 1x, http://example.com/post/img/a@2x.png 2x\"/>"+
+		"<picture><source srcset=\"http://example.com/b.webp 640w, http://example.com/post/b-big.webp 1280w\"/></picture>"+
+		"</body></html>")
+}